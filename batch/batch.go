@@ -0,0 +1,138 @@
+// Package batch	批量下单; 从 csv/jsonl 文件读取数据, 并发报价下单并输出结果文件 (支持断点续跑)
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+)
+
+// Booker	一键下单接口 (*lalamove.Client 已实现)
+type Booker interface {
+	Book(req lalamove.BookRequest) (*lalamove.BookResult, error)
+}
+
+// Runner	批量下单执行器
+type Runner struct {
+	Booker Booker
+	// 最大并发数; 默认 1
+	Concurrency int
+	// 相邻两次下单的最小间隔; 为 0 时不限速
+	Interval time.Duration
+	// 可选; 每次限速等待结束后回调等待时长
+	OnWait func(d time.Duration)
+}
+
+// Run	执行批量下单; 已在 done 中的行将被跳过, 每行结果写入 w (可为 nil).
+// ctx 取消后不再开始新的行, 已开始的行会执行完毕; 行标识重复时不执行
+func (r *Runner) Run(ctx context.Context, rows []Row, done map[string]bool, w *ResultWriter) ([]Result, error) {
+	if err := checkIDs(rows); err != nil {
+		return nil, err
+	}
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var tick <-chan time.Time
+	if r.Interval > 0 {
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	jobs := make(chan Row)
+	results := make(chan Result)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				results <- r.book(row)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(jobs)
+		first := true
+		for _, row := range rows {
+			if done[row.ID] {
+				continue
+			}
+			// 限速
+			if tick != nil && !first {
+				start := time.Now()
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+				if r.OnWait != nil {
+					r.OnWait(time.Since(start))
+				}
+			}
+			first = false
+
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- row:
+			}
+		}
+	}()
+
+	// 收集结果直到所有已派发的行完成
+	var err error
+	collected := make([]Result, 0, len(rows))
+	for res := range results {
+		collected = append(collected, res)
+		if w != nil {
+			if werr := w.Write(res); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return collected, err
+}
+
+// book	单行下单
+func (r *Runner) book(row Row) Result {
+	res := Result{RowID: row.ID}
+	if err := row.Validate(); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	booked, err := r.Booker.Book(row.BookRequest())
+	if booked != nil {
+		if booked.Quotation != nil {
+			res.QuotationID = booked.Quotation.ID
+			res.Price = booked.Quotation.PriceBreakdown.Total
+			res.Currency = booked.Quotation.PriceBreakdown.Currency
+		}
+		if booked.Order != nil {
+			res.OrderID = booked.Order.ID
+			if booked.Order.PriceBreakdown.Total != "" {
+				res.Price = booked.Order.PriceBreakdown.Total
+				res.Currency = booked.Order.PriceBreakdown.Currency
+			}
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,166 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+const csvInput = `id,serviceType,language,pickupAddress,pickupLat,pickupLng,senderName,senderPhone,dropoffAddress,dropoffLat,dropoffLng,recipientName,recipientPhone,remarks
+r1,MOTORCYCLE,en_HK,Innocentre,22.3354,114.1761,Michal,+85238485765,Canton Rd,22.2955,114.1688,Katrina,+85238485760,fragile
+r2,MOTORCYCLE,en_HK,Innocentre,22.3354,114.1761,Michal,+85238485765,Cyberport,22.2630,114.1308,Fail,+85238485761,
+r3,MOTORCYCLE,en_HK,Innocentre,22.3354,114.1761,Michal,+85238485765,,,,Katrina,+85238485760,
+`
+
+type fakeBooker struct {
+	mu sync.Mutex
+	calls []lalamove.BookRequest
+}
+
+func (f *fakeBooker) Book(req lalamove.BookRequest) (*lalamove.BookResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, req)
+	f.mu.Unlock()
+
+	result := &lalamove.BookResult{
+		Quotation: &quotation.QuotationDetail{
+			ID: "Q" + req.Metadata["batchRowId"],
+			PriceBreakdown: quotation.PriceBreakdown{Total: "100", Currency: "HKD"},
+		},
+	}
+	if req.Recipients[1].Name == "Fail" {
+		return result, errors.New("[ERR_INSUFFICIENT_CREDIT] insufficient credit")
+	}
+	result.Order = &order.OrderDetail{ID: "O" + req.Metadata["batchRowId"]}
+	return result, nil
+}
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(csvInput))
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "r1", rows[0].ID)
+		assert.Equal(t, "fragile", rows[0].Remarks)
+		assert.NoError(t, rows[0].Validate())
+		assert.Error(t, rows[2].Validate())
+
+		q := rows[0].Quotation()
+		assert.Len(t, q.Stops, 2)
+		assert.Equal(t, "22.2955", q.Stops[1].Coordinates.Lat)
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	rows, err := ReadJSONL(strings.NewReader(`{"id":"r1","serviceType":"VAN"}` + "\n\n" + `{"id":"r2"}` + "\n"))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	_, err = ReadJSONL(strings.NewReader("{bad"))
+	assert.Error(t, err)
+}
+
+func TestDuplicateRowID(t *testing.T) {
+	_, err := ReadJSONL(strings.NewReader(`{"id":"r1"}` + "\n" + `{"id":"r2"}` + "\n" + `{"id":"r1"}` + "\n"))
+	assert.ErrorContains(t, err, `duplicate row id "r1" (rows 1 and 3)`)
+	_, err = ReadCSV(strings.NewReader(csvInput + "r2" + strings.Repeat(",", 13) + "\n"))
+	assert.ErrorContains(t, err, `duplicate row id "r2"`)
+
+	rows, _ := ReadCSV(strings.NewReader(csvInput))
+	booker := &fakeBooker{}
+	_, err = (&Runner{Booker: booker}).Run(context.Background(), append(rows, rows[0]), nil, nil)
+	assert.Error(t, err)
+	assert.Empty(t, booker.calls)
+}
+
+func TestCompletedNeedsReview(t *testing.T) {
+	results := []Result{
+		{RowID: "r1", OrderID: "O1"},
+		// 已下单但取消失败: 续跑时不可重复下单
+		{RowID: "r2", OrderID: "O2", Error: "book: order mismatch (cancel order O2: timeout)"},
+		{RowID: "r3", Error: "[ERR_INSUFFICIENT_CREDIT] insufficient credit"},
+	}
+	assert.Equal(t, map[string]bool{"r1": true, "r2": true}, Completed(results))
+	assert.False(t, results[1].Done())
+	assert.True(t, results[1].NeedsReview())
+	assert.False(t, results[2].NeedsReview())
+}
+
+func TestRunResume(t *testing.T) {
+	rows, _ := ReadCSV(strings.NewReader(csvInput))
+	for _, ext := range []string{".csv", ".jsonl"} {
+		path := filepath.Join(t.TempDir(), "results"+ext)
+
+		w, err := OpenResults(path)
+		assert.NoError(t, err)
+		booker := &fakeBooker{}
+		runner := &Runner{Booker: booker, Concurrency: 2}
+		results, err := runner.Run(context.Background(), rows, nil, w)
+		w.Close()
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		// r3 未通过校验, 不会调用下单
+		assert.Len(t, booker.calls, 2)
+
+		saved, err := ReadResults(path)
+		assert.NoError(t, err)
+		assert.Len(t, saved, 3)
+		done := Completed(saved)
+		assert.Equal(t, map[string]bool{"r1": true}, done)
+		for _, r := range saved {
+			if r.RowID == "r1" {
+				assert.Equal(t, "Or1", r.OrderID)
+				assert.Equal(t, "100", r.Price)
+			}
+		}
+
+		// 断点续跑: 已完成的行被跳过
+		w, _ = OpenResults(path)
+		booker = &fakeBooker{}
+		runner.Booker = booker
+		results, err = runner.Run(context.Background(), rows, done, w)
+		w.Close()
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Len(t, booker.calls, 1)
+
+		saved, _ = ReadResults(path)
+		assert.Len(t, saved, 5)
+		assert.Len(t, Latest(saved), 3)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	rows, _ := ReadCSV(strings.NewReader(csvInput))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := &Runner{Booker: &fakeBooker{}}
+	results, err := runner.Run(ctx, rows, nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, len(results), 1)
+}
+
+func TestRunInterval(t *testing.T) {
+	rows, _ := ReadCSV(strings.NewReader(csvInput))
+	waits := 0
+	runner := &Runner{
+		Booker: &fakeBooker{},
+		Interval: 5 * time.Millisecond,
+		OnWait: func(d time.Duration) { waits++ },
+	}
+	results, err := runner.Run(context.Background(), rows, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	// 首行不等待
+	assert.Equal(t, 2, waits)
+}
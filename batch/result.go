@@ -0,0 +1,171 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Result	单行下单结果
+type Result struct {
+	RowID string `json:"rowId"`
+	QuotationID string `json:"quotationId"`
+	OrderID string `json:"orderId"`
+	Price string `json:"price"`
+	Currency string `json:"currency"`
+	Error string `json:"error"`
+}
+
+// Done	是否已成功下单
+func (r Result) Done() bool {
+	return r.OrderID != "" && r.Error == ""
+}
+
+// Placed	是否已创建订单 (含下单后出错的行); 续跑时不再重复下单
+func (r Result) Placed() bool {
+	return r.OrderID != ""
+}
+
+// NeedsReview	已创建订单但随后出错 (如校验失败后取消订单失败), 需人工处理
+func (r Result) NeedsReview() bool {
+	return r.OrderID != "" && r.Error != ""
+}
+
+var resultColumns = []string{"rowId", "quotationId", "orderId", "price", "currency", "error"}
+
+func (r Result) record() []string {
+	return []string{r.RowID, r.QuotationID, r.OrderID, r.Price, r.Currency, r.Error}
+}
+
+// ResultWriter	结果写入 (并发安全)
+type ResultWriter struct {
+	mu sync.Mutex
+	file *os.File
+	jsonl bool
+	csv *csv.Writer
+}
+
+// OpenResults	以追加方式打开结果文件; 根据扩展名识别格式 (.csv / .jsonl)
+func OpenResults(path string) (*ResultWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0664)
+	if err != nil {
+		return nil, err
+	}
+	w := &ResultWriter{file: file, jsonl: isJSONL(path)}
+	if !w.jsonl {
+		w.csv = csv.NewWriter(file)
+		// 新文件写入表头
+		if info, err := file.Stat(); err == nil && info.Size() == 0 {
+			w.csv.Write(resultColumns)
+			w.csv.Flush()
+		}
+	}
+	return w, nil
+}
+
+// Write	写入一条结果并立即落盘
+func (w *ResultWriter) Write(r Result) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.jsonl {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.file.Write(append(line, '\n'))
+		return err
+	}
+
+	w.csv.Write(r.record())
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close	关闭结果文件
+func (w *ResultWriter) Close() error {
+	return w.file.Close()
+}
+
+// ReadResults	读取已有结果文件; 文件不存在时返回空结果
+func ReadResults(path string) ([]Result, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	results := make([]Result, 0)
+	if isJSONL(path) {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			r := Result{}
+			if err := json.Unmarshal([]byte(text), &r); err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+		return results, scanner.Err()
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < len(resultColumns) || record[0] == resultColumns[0] {
+			continue
+		}
+		results = append(results, Result{
+			RowID: record[0],
+			QuotationID: record[1],
+			OrderID: record[2],
+			Price: record[3],
+			Currency: record[4],
+			Error: record[5],
+		})
+	}
+	return results, nil
+}
+
+// Completed	返回已创建订单的行标识 (含需人工处理的行), 续跑时跳过以免重复下单
+func Completed(results []Result) map[string]bool {
+	done := make(map[string]bool, len(results))
+	for _, r := range results {
+		if r.Placed() {
+			done[r.RowID] = true
+		}
+	}
+	return done
+}
+
+// Latest	返回每行最后一次的结果 (按首次出现顺序)
+func Latest(results []Result) []Result {
+	index := make(map[string]int, len(results))
+	latest := make([]Result, 0, len(results))
+	for _, r := range results {
+		if i, ok := index[r.RowID]; ok {
+			latest[i] = r
+			continue
+		}
+		index[r.RowID] = len(latest)
+		latest = append(latest, r)
+	}
+	return latest
+}
@@ -0,0 +1,208 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// Row	批量下单的一行数据 (一个取货点, 一个送货点)
+type Row struct {
+	// 行唯一标识; 用于断点续跑
+	ID string `json:"id"`
+	ServiceType string `json:"serviceType"`
+	Language string `json:"language"`
+
+	PickupAddress string `json:"pickupAddress"`
+	PickupLat string `json:"pickupLat"`
+	PickupLng string `json:"pickupLng"`
+	SenderName string `json:"senderName"`
+	SenderPhone string `json:"senderPhone"`
+
+	DropoffAddress string `json:"dropoffAddress"`
+	DropoffLat string `json:"dropoffLat"`
+	DropoffLng string `json:"dropoffLng"`
+	RecipientName string `json:"recipientName"`
+	RecipientPhone string `json:"recipientPhone"`
+	Remarks string `json:"remarks"`
+}
+
+// csv 表头与字段对应关系
+var csvColumns = []string{
+	"id", "serviceType", "language",
+	"pickupAddress", "pickupLat", "pickupLng", "senderName", "senderPhone",
+	"dropoffAddress", "dropoffLat", "dropoffLng", "recipientName", "recipientPhone", "remarks",
+}
+
+func (row *Row) fields() []*string {
+	return []*string{
+		&row.ID, &row.ServiceType, &row.Language,
+		&row.PickupAddress, &row.PickupLat, &row.PickupLng, &row.SenderName, &row.SenderPhone,
+		&row.DropoffAddress, &row.DropoffLat, &row.DropoffLng, &row.RecipientName, &row.RecipientPhone, &row.Remarks,
+	}
+}
+
+// Validate	校验必填字段
+func (row Row) Validate() error {
+	if row.ID == "" {
+		return errors.New("id is required")
+	}
+	required := map[string]string{
+		"serviceType": row.ServiceType,
+		"pickupAddress": row.PickupAddress,
+		"pickupLat": row.PickupLat,
+		"pickupLng": row.PickupLng,
+		"senderName": row.SenderName,
+		"senderPhone": row.SenderPhone,
+		"dropoffAddress": row.DropoffAddress,
+		"dropoffLat": row.DropoffLat,
+		"dropoffLng": row.DropoffLng,
+		"recipientName": row.RecipientName,
+		"recipientPhone": row.RecipientPhone,
+	}
+	for _, name := range csvColumns {
+		if val, ok := required[name]; ok && val == "" {
+			return fmt.Errorf("row %s: %s is required", row.ID, name)
+		}
+	}
+	return nil
+}
+
+// Quotation	构建报价单
+func (row Row) Quotation() *quotation.Quotation {
+	return &quotation.Quotation{
+		ServiceType: row.ServiceType,
+		Language: row.Language,
+		Stops: []quotation.DeliveryStop{
+			{
+				Address: row.PickupAddress,
+				Coordinates: quotation.Coordinates{Lat: row.PickupLat, Lng: row.PickupLng},
+			},
+			{
+				Address: row.DropoffAddress,
+				Coordinates: quotation.Coordinates{Lat: row.DropoffLat, Lng: row.DropoffLng},
+			},
+		},
+	}
+}
+
+// BookRequest	构建一键下单请求; 订单 (order.Order) 在报价后由 Client.Book 生成
+func (row Row) BookRequest() lalamove.BookRequest {
+	return lalamove.BookRequest{
+		Quotation: row.Quotation(),
+		Sender: order.Contact{Name: row.SenderName, Phone: row.SenderPhone},
+		Recipients: map[int]order.DeliveryDetail{
+			1: {Name: row.RecipientName, Phone: row.RecipientPhone, Remarks: row.Remarks},
+		},
+		Metadata: map[string]string{"batchRowId": row.ID},
+	}
+}
+
+// ReadFile	读取批量数据文件; 根据扩展名识别格式 (.csv / .jsonl)
+func ReadFile(path string) ([]Row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if isJSONL(path) {
+		return ReadJSONL(file)
+	}
+	return ReadCSV(file)
+}
+
+// ReadCSV	读取csv数据; 首行为表头, 列名与 Row 的json标签一致
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+
+	rows := make([]Row, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := Row{}
+		fields := row.fields()
+		for i, name := range csvColumns {
+			if col, ok := index[name]; ok && col < len(record) {
+				*fields[i] = strings.TrimSpace(record[col])
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := checkIDs(rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ReadJSONL	读取jsonl数据; 每行一个 Row
+func ReadJSONL(r io.Reader) ([]Row, error) {
+	rows := make([]Row, 0)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := Row{}
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := checkIDs(rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// checkIDs	校验行标识不重复 (续跑依赖行标识); 空标识由 Validate 处理
+func checkIDs(rows []Row) error {
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if row.ID == "" {
+			continue
+		}
+		if j, ok := seen[row.ID]; ok {
+			return fmt.Errorf("duplicate row id %q (rows %d and %d)", row.ID, j+1, i+1)
+		}
+		seen[row.ID] = i
+	}
+	return nil
+}
+
+func isJSONL(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".jsonl" || ext == ".ndjson"
+}
@@ -0,0 +1,268 @@
+// Package cassette	录制及回放 HTTP 请求 (http.RoundTripper); 用于离线、可重复的集成测试.
+// 录制时屏蔽 apikey/secret 及随时间变化的 Authorization 请求头, 回放时按请求方法、路径及请求体匹配
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/eddielau42/lalamove-go-api/logger"
+)
+
+// 模式
+const (
+	// 仅回放; 无匹配记录时返回错误
+	MODE_REPLAY = "replay"
+	// 访问真实接口并录制 (覆盖已有记录)
+	MODE_RECORD = "record"
+	// 记录文件存在时回放, 否则录制
+	MODE_AUTO = "auto"
+)
+
+// 不录制的请求头 (每次请求均不同, 不参与匹配)
+var volatileHeaders = []string{"Request-Id", "Traceparent", "Tracestate"}
+
+// ErrNoInteraction	没有匹配的录制记录
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// Request	录制的请求
+type Request struct {
+	Method string `json:"method"`
+	// 路径及查询参数 (不含域名)
+	URL string `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body string `json:"body,omitempty"`
+}
+
+// Response	录制的响应
+type Response struct {
+	StatusCode int `json:"statusCode"`
+	Header http.Header `json:"header,omitempty"`
+	Body string `json:"body,omitempty"`
+}
+
+// Interaction	一次请求及响应
+type Interaction struct {
+	Request Request `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Transport	录制/回放 http.RoundTripper
+type Transport struct {
+	path string
+	mode string
+	next http.RoundTripper
+	redactor *logger.Redactor
+
+	mu sync.Mutex
+	interactions []Interaction
+	used []bool
+}
+
+// New	创建录制/回放器; path 为记录文件路径, secrets 为录制时需屏蔽的字符串 (如 apikey/secret)
+func New(path, mode string, secrets ...string) (*Transport, error) {
+	t := &Transport{
+		path: path,
+		mode: mode,
+		next: http.DefaultTransport,
+		redactor: logger.NewRedactor(
+			[]logger.Rule{
+				{Keys: []string{"authorization"}, Mask: logger.MaskAuthorization},
+			},
+			[]logger.Pattern{
+				{Regexp: regexp.MustCompile(`\b(pk|sk)_(test|prod)_[0-9A-Za-z*]+`), Replace: "${1}_${2}_" + logger.MASK},
+			},
+		).WithSecrets(secrets...),
+	}
+
+	switch mode {
+	case MODE_REPLAY:
+		if err := t.load(); err != nil {
+			return nil, err
+		}
+	case MODE_RECORD:
+	case MODE_AUTO:
+		if _, err := os.Stat(path); err == nil {
+			t.mode = MODE_REPLAY
+			if err := t.load(); err != nil {
+				return nil, err
+			}
+		} else {
+			t.mode = MODE_RECORD
+		}
+	default:
+		return nil, fmt.Errorf("cassette: unknown mode %q", mode)
+	}
+	return t, nil
+}
+
+// SetTransport	设置录制时实际发送请求的 RoundTripper; 默认 http.DefaultTransport
+func (t *Transport) SetTransport(next http.RoundTripper) *Transport {
+	t.next = next
+	return t
+}
+
+// Mode	当前模式 (MODE_AUTO 解析后为 MODE_REPLAY 或 MODE_RECORD)
+func (t *Transport) Mode() string {
+	return t.mode
+}
+
+// Interactions	已加载或已录制的记录
+func (t *Transport) Interactions() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Interaction{}, t.interactions...)
+}
+
+// RoundTrip	实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := t.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.mode == MODE_REPLAY {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		i := t.match(recorded)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+		}
+		t.used[i] = true
+		return t.interactions[i].Response.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interactions = append(t.interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header: t.redactor.Header(resp.Header),
+			Body: t.scrub(body),
+		},
+	})
+	t.used = append(t.used, true)
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// request	转换为录制格式 (已脱敏)
+func (t *Transport) request(req *http.Request) (Request, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return Request{}, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header := req.Header.Clone()
+	for _, key := range volatileHeaders {
+		header.Del(key)
+	}
+	return Request{
+		Method: req.Method,
+		URL: req.URL.RequestURI(),
+		Header: t.redactor.Header(header),
+		Body: t.scrub(body),
+	}, nil
+}
+
+// scrub	屏蔽敏感内容; json 内容同时规范化 (字段排序), 便于匹配
+func (t *Transport) scrub(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	return t.redactor.String(string(body))
+}
+
+// match	查找匹配的记录; 优先使用未回放过的记录, 否则重复使用最后一条匹配记录
+func (t *Transport) match(req Request) int {
+	last := -1
+	for i, recorded := range t.interactions {
+		if recorded.Request.Method != req.Method || recorded.Request.URL != req.URL || recorded.Request.Body != req.Body {
+			continue
+		}
+		if !t.used[i] {
+			return i
+		}
+		last = i
+	}
+	return last
+}
+
+// response	构造回放响应
+func (r Response) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: r.StatusCode,
+		Status: strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		Proto: "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: header,
+		Body: io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request: req,
+	}
+}
+
+// load	读取记录文件; 请求体按录制规则规范化, 允许手工编辑记录文件
+func (t *Transport) load() error {
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	cassette := struct {
+		Interactions []Interaction `json:"interactions"`
+	}{}
+	if err := json.Unmarshal(content, &cassette); err != nil {
+		return fmt.Errorf("cassette: %s: %w", t.path, err)
+	}
+	for i := range cassette.Interactions {
+		cassette.Interactions[i].Request.Body = t.scrub([]byte(cassette.Interactions[i].Request.Body))
+	}
+	t.interactions = cassette.Interactions
+	t.used = make([]bool, len(t.interactions))
+	return nil
+}
+
+// save	写入记录文件
+func (t *Transport) save() error {
+	content, err := json.MarshalIndent(map[string]interface{}{"interactions": t.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, append(content, '\n'), 0644)
+}
@@ -0,0 +1,99 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testKey = "pk_test_0123456789abcdef"
+	testSecret = "sk_test_fedcba9876543210"
+)
+
+func send(t *testing.T, client *http.Client, method, url, body string) (int, string, error) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "hmac "+testKey+":1685613600000:0123abcd")
+	req.Header.Set("Request-ID", "volatile")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(content), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"echo":` + string(body) + `,"owner":"` + testKey + `"}}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	recorder, err := New(path, MODE_AUTO, testKey, testSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, MODE_RECORD, recorder.Mode())
+
+	client := &http.Client{Transport: recorder}
+	status, body, err := send(t, client, http.MethodPost, srv.URL+"/v3/quotations", `{"data":{"b":2,"a":1}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Contains(t, body, testKey)
+	_, _, err = send(t, client, http.MethodDelete, srv.URL+"/v3/orders/O1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// 记录文件不包含密钥及签名
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), testKey)
+	assert.NotContains(t, string(content), "0123abcd")
+	assert.NotContains(t, string(content), "volatile")
+
+	// 回放时不访问服务; json 字段顺序不影响匹配
+	srv.Close()
+	player, err := New(path, MODE_AUTO, testKey, testSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, MODE_REPLAY, player.Mode())
+	assert.Len(t, player.Interactions(), 2)
+
+	client = &http.Client{Transport: player}
+	status, body, err = send(t, client, http.MethodPost, "https://rest.sandbox.lalamove.com/v3/quotations", `{"data":{"a":1,"b":2}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Contains(t, body, `"a":1`)
+	assert.Contains(t, body, `"owner":"***"`)
+
+	status, _, err = send(t, client, http.MethodDelete, "https://rest.sandbox.lalamove.com/v3/orders/O1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Equal(t, 2, calls)
+
+	// 请求体不同则不匹配
+	_, _, err = send(t, client, http.MethodPost, "https://rest.sandbox.lalamove.com/v3/quotations", `{"data":{"a":2}}`)
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+}
+
+func TestReplayMissingFile(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), MODE_REPLAY)
+	assert.Error(t, err)
+
+	_, err = New("cassette.json", "unknown")
+	assert.Error(t, err)
+}
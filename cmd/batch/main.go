@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/batch"
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+)
+
+const usage = `用法:
+  batch run    -input rows.csv -output results.csv [-apikey ..] [-secret ..] [-market HK] [-concurrency 4] [-interval 200ms]
+  batch report -output results.csv
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "run":
+		os.Exit(run(os.Args[2:]))
+	case "report":
+		os.Exit(report(os.Args[2:]))
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+}
+
+// run	执行批量下单; 返回退出码 (在 main 中退出, 以便 defer 执行)
+func run(args []string) int {
+	var (
+		apikey, secret, market string
+		input, output string
+		concurrency int
+		interval time.Duration
+	)
+
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.StringVar(&apikey, "apikey", os.Getenv("LALAMOVE_APIKEY"), "apikey")
+	fs.StringVar(&secret, "secret", os.Getenv("LALAMOVE_SECRET"), "secret")
+	fs.StringVar(&market, "market", os.Getenv("LALAMOVE_MARKET"), "地区")
+	fs.StringVar(&input, "input", "", "数据文件 (.csv / .jsonl)")
+	fs.StringVar(&output, "output", "", "结果文件 (.csv / .jsonl); 已存在时跳过已成功的行")
+	fs.IntVar(&concurrency, "concurrency", 4, "最大并发数")
+	fs.DurationVar(&interval, "interval", 200*time.Millisecond, "相邻两次下单的最小间隔")
+	fs.Parse(args)
+
+	if apikey == "" || secret == "" {
+		fmt.Println("请输入apikey和secret!")
+		return 2
+	}
+	if input == "" || output == "" {
+		fmt.Println("请输入数据文件和结果文件!")
+		return 2
+	}
+	if market == "" {
+		market = enum.AREA_CODE_HK
+	}
+
+	rows, err := batch.ReadFile(input)
+	if err != nil {
+		fmt.Println("----- 读取数据文件失败: " + err.Error())
+		return 1
+	}
+	previous, err := batch.ReadResults(output)
+	if err != nil {
+		fmt.Println("----- 读取结果文件失败: " + err.Error())
+		return 1
+	}
+	done := batch.Completed(previous)
+
+	w, err := batch.OpenResults(output)
+	if err != nil {
+		fmt.Println("----- 打开结果文件失败: " + err.Error())
+		return 1
+	}
+	defer w.Close()
+
+	cli := lalamove.NewClient(lalamove.Config{
+		Apikey: apikey,
+		Secret: secret,
+		Country: market,
+	})
+	// Check sandbox
+	if !strings.Contains(apikey, "pk_prod") || !strings.Contains(secret, "sk_prod") {
+		cli.Sandbox()
+	}
+
+	// 中断后可再次执行以续跑
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf(">>> 共 %d 行, 已完成 %d 行, 开始下单...\n", len(rows), len(done))
+	runner := &batch.Runner{
+		Booker: cli,
+		Concurrency: concurrency,
+		Interval: interval,
+	}
+	results, err := runner.Run(ctx, rows, done, w)
+	printSummary(results)
+	if err != nil {
+		fmt.Println("<<< 已中断: " + err.Error())
+		return 1
+	}
+	return 0
+}
+
+// report	统计结果文件; 返回退出码
+func report(args []string) int {
+	var output string
+
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	fs.StringVar(&output, "output", "", "结果文件 (.csv / .jsonl)")
+	fs.Parse(args)
+
+	if output == "" {
+		fmt.Println("请输入结果文件!")
+		return 2
+	}
+
+	results, err := batch.ReadResults(output)
+	if err != nil {
+		fmt.Println("----- 读取结果文件失败: " + err.Error())
+		return 1
+	}
+	printSummary(batch.Latest(results))
+	return 0
+}
+
+func printSummary(results []batch.Result) {
+	success, failed, review := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Done():
+			success++
+		case r.NeedsReview():
+			review++
+			fmt.Printf("!!!!! [%s] 已下单 %s, 需人工处理: %s\n", r.RowID, r.OrderID, r.Error)
+		default:
+			failed++
+			fmt.Printf("----- [%s] %s\n", r.RowID, r.Error)
+		}
+	}
+	fmt.Printf("<<< 成功 %d 行, 失败 %d 行, 需人工处理 %d 行。\n", success, failed, review)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// generate	向本地地址推送模拟的订单生命周期事件
+func generate(args []string) {
+	var (
+		target, apikey, secret, orderID, driverID, final, events string
+		step time.Duration
+		speed float64
+	)
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	fs.StringVar(&target, "url", "", "接收事件的地址")
+	fs.StringVar(&apikey, "apikey", os.Getenv("LALAMOVE_APIKEY"), "apikey")
+	fs.StringVar(&secret, "secret", os.Getenv("LALAMOVE_SECRET"), "签名使用的secret")
+	fs.StringVar(&orderID, "order", "1000000000000000000", "订单ID")
+	fs.StringVar(&driverID, "driver", "80557", "司机ID")
+	fs.StringVar(&final, "final", enum.ORDER_STATUS_COMPLETED, "订单最终状态")
+	fs.StringVar(&events, "events", "", "额外推送的事件类型; 多个以逗号分隔 (ORDER_AMOUNT_CHANGED,ORDER_EDITED,ORDER_REPLACED,WALLET_BALANCE_CHANGED)")
+	fs.DurationVar(&step, "step", time.Minute, "相邻事件的时间间隔")
+	fs.Float64Var(&speed, "speed", 0, "推送间隔倍率; 0 表示不等待, 1 表示按事件时间间隔实时推送")
+	fs.Parse(args)
+
+	if target == "" || secret == "" {
+		fmt.Println("请输入接收地址和secret!")
+		os.Exit(2)
+	}
+
+	gen := &webhook.Generator{Apikey: apikey, Secret: secret}
+	od := order.OrderDetail{ID: orderID}
+	d := driver.DriverDetail{
+		ID: driverID,
+		PlateNo: "VP9946964",
+		Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"},
+		Driver: driver.Driver{Name: "David", Phone: "+85212345678"},
+	}
+
+	sequence := gen.Lifecycle(od, d, webhook.LifecycleOptions{Step: step, Final: strings.ToUpper(final)})
+	for _, eventType := range strings.Split(events, ",") {
+		switch strings.ToUpper(strings.TrimSpace(eventType)) {
+		case "":
+		case webhook.EVENT_ORDER_AMOUNT_CHANGED:
+			sequence = append(sequence, gen.OrderAmountChanged(od))
+		case webhook.EVENT_ORDER_EDITED:
+			sequence = append(sequence, gen.OrderEdited(od))
+		case webhook.EVENT_ORDER_REPLACED:
+			sequence = append(sequence, gen.OrderReplaced(od, orderID+"0"))
+		case webhook.EVENT_WALLET_BALANCE_CHANGED:
+			sequence = append(sequence, gen.WalletBalanceChanged("HKD", "1000"))
+		default:
+			fmt.Printf("不支持的事件类型: %s\n", eventType)
+			os.Exit(2)
+		}
+	}
+
+	gen.OnSent = printEvent
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := gen.Send(ctx, &http.Client{Timeout: 10 * time.Second}, target, sequence, speed)
+	stop()
+	if err != nil {
+		fmt.Println("<<< 推送失败: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("<<< 已推送 %d 个事件。\n", len(sequence))
+}
@@ -18,6 +18,16 @@ const (
 	envPrefix = "LALAMOVE_"
 )
 
+const usage = `用法:
+  webhook -url https://your.webhook.link [-apikey ..] [-secret ..] [-market HK]   设置webhook地址
+  webhook serve -secret .. [-apikey ..] [-addr :8080] [-path /webhook] [-store events.jsonl] [-forward http://localhost:3000/hook]
+  webhook redeliver -store events.jsonl -url http://localhost:3000/hook [-secret ..] [-event eventId]
+  webhook generate -url http://localhost:3000/hook -secret .. [-order ..] [-driver ..] [-final COMPLETED] [-step 1m] [-speed 0] [-events ORDER_EDITED,..]
+  webhook status [-apikey ..] [-secret ..] [-market HK,TW]                        查询各市场当前地址
+  webhook check  -url https://your.webhook.link [-secret ..] [-require-signature] 预检目标地址
+  webhook switch -url https://your.webhook.link [-apikey ..] [-secret ..] [-market HK,TW] [-require-signature]
+`
+
 var (
 	cli *lalamove.Client
 
@@ -35,6 +45,32 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "redeliver":
+			redeliver(os.Args[2:])
+			return
+		case "generate":
+			generate(os.Args[2:])
+			return
+		case "status":
+			status(os.Args[2:])
+			return
+		case "check":
+			check(os.Args[2:])
+			return
+		case "switch":
+			switchURL(os.Args[2:])
+			return
+		case "help", "-h", "-help", "--help":
+			fmt.Print(usage)
+			return
+		}
+	}
+
 	flag.StringVar(&apikey, "apikey", "", "apikey")
 	flag.StringVar(&secret, "secret", "", "secret")
 	flag.StringVar(&market, "market", "", "地区")
@@ -122,6 +158,10 @@ func loadEnv(envDir string) error {
 	}
 
 	file, err := os.Open(envDir)
+	if os.IsNotExist(err) {
+		// 未提供配置文件时使用命令行参数
+		return nil
+	}
 	if err != nil {
 		log.Fatalln(err)
 		return err
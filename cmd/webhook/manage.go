@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// 全部市场
+var allMarkets = []string{
+	enum.AREA_CODE_BR, enum.AREA_CODE_HK, enum.AREA_CODE_ID, enum.AREA_CODE_MY, enum.AREA_CODE_MX,
+	enum.AREA_CODE_PH, enum.AREA_CODE_SG, enum.AREA_CODE_TW, enum.AREA_CODE_TH, enum.AREA_CODE_VN,
+}
+
+// manageFlags	管理命令的公共参数
+type manageFlags struct {
+	apikey, secret, markets string
+	requireSignature bool
+}
+
+func (f *manageFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apikey, "apikey", os.Getenv("LALAMOVE_APIKEY"), "apikey")
+	fs.StringVar(&f.secret, "secret", os.Getenv("LALAMOVE_SECRET"), "secret")
+	fs.StringVar(&f.markets, "market", os.Getenv("LALAMOVE_MARKET"), "地区; 多个以逗号分隔, 为空时使用全部市场 (status) 或 HK")
+	fs.BoolVar(&f.requireSignature, "require-signature", false, "要求目标地址拒绝签名错误的事件")
+}
+
+// marketList	解析地区参数
+func (f *manageFlags) marketList(fallback []string) []string {
+	if f.markets == "" {
+		return fallback
+	}
+	markets := make([]string, 0)
+	for _, m := range strings.Split(f.markets, ",") {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			markets = append(markets, m)
+		}
+	}
+	return markets
+}
+
+// manager	创建指定市场的地址管理器
+func (f *manageFlags) manager(market string) *webhook.Manager {
+	cli := lalamove.NewClient(lalamove.Config{
+		Apikey: f.apikey,
+		Secret: f.secret,
+		Country: market,
+	})
+	// Check sandbox
+	if !strings.Contains(f.apikey, "pk_prod") || !strings.Contains(f.secret, "sk_prod") {
+		cli.Sandbox()
+	}
+	return &webhook.Manager{
+		Client: cli,
+		Apikey: f.apikey,
+		Secret: f.secret,
+		RequireSignatureCheck: f.requireSignature,
+	}
+}
+
+func (f *manageFlags) require() {
+	if f.apikey == "" || f.secret == "" {
+		fmt.Println("请输入apikey和secret!")
+		os.Exit(2)
+	}
+}
+
+// status	查询各市场当前webhook地址
+func status(args []string) {
+	f := &manageFlags{}
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	f.register(fs)
+	fs.Parse(args)
+	f.require()
+
+	for _, market := range f.marketList(allMarkets) {
+		current, err := f.manager(market).Current()
+		switch {
+		case errors.Is(err, lalamove.ErrWebhookUnsupported):
+			fmt.Printf("[%s] 未知 (当前环境不支持查询)\n", market)
+		case err != nil:
+			fmt.Printf("[%s] 查询失败: %s\n", market, err.Error())
+		case current == "":
+			fmt.Printf("[%s] 未设置\n", market)
+		default:
+			fmt.Printf("[%s] %s\n", market, current)
+		}
+	}
+}
+
+// check	预检目标地址
+func check(args []string) {
+	var target string
+	f := &manageFlags{}
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	f.register(fs)
+	fs.StringVar(&target, "url", "", "要预检的webhook地址")
+	fs.Parse(args)
+
+	if f.secret == "" || target == "" {
+		fmt.Println("请输入secret和webhook地址!")
+		os.Exit(2)
+	}
+
+	result, err := f.manager(enum.AREA_CODE_HK).Check(context.Background(), target)
+	printCheck(result)
+	if err != nil {
+		fmt.Println("<<< 预检失败: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("<<< 预检通过。")
+}
+
+// switchURL	预检后切换各市场的webhook地址, 失败时恢复原地址
+func switchURL(args []string) {
+	var target string
+	f := &manageFlags{}
+	fs := flag.NewFlagSet("switch", flag.ExitOnError)
+	f.register(fs)
+	fs.StringVar(&target, "url", "", "新的webhook地址")
+	fs.Parse(args)
+	f.require()
+
+	if target == "" {
+		fmt.Println("请输入webhook地址!")
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, market := range f.marketList([]string{enum.AREA_CODE_HK}) {
+		fmt.Printf(">>> [%s] 切换webhook地址...\n", market)
+		result, err := f.manager(market).Switch(context.Background(), target)
+		printCheck(result.Check)
+		if err != nil {
+			failed++
+			fmt.Printf("<<< [%s] 切换失败: %s\n", market, err.Error())
+			if result.RolledBack {
+				fmt.Printf("<<< [%s] 已恢复为 %s\n", market, result.Previous)
+			}
+			continue
+		}
+		fmt.Printf("<<< [%s] %s -> %s\n", market, displayURL(result.Previous), result.Current)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func printCheck(result *webhook.CheckResult) {
+	if result == nil {
+		return
+	}
+	fmt.Printf("----- 预检 %s: 状态码 %d, 耗时 %s, 校验签名: %t\n", result.URL, result.StatusCode, result.Latency, result.VerifiesSignature)
+}
+
+func displayURL(url string) string {
+	if url == "" {
+		return "(未知)"
+	}
+	return url
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// serve	本地接收webhook事件; 校验签名后打印事件内容并写入事件文件
+func serve(args []string) {
+	var (
+		apikey, secret string
+		addr, path, storePath, forward string
+	)
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&apikey, "apikey", os.Getenv("LALAMOVE_APIKEY"), "apikey; 不为空时校验事件中的apikey")
+	fs.StringVar(&secret, "secret", os.Getenv("LALAMOVE_SECRET"), "secret; 用于校验签名")
+	fs.StringVar(&addr, "addr", ":8080", "监听地址")
+	fs.StringVar(&path, "path", "/webhook", "接收路径")
+	fs.StringVar(&storePath, "store", "webhook-events.jsonl", "事件文件 (.jsonl)")
+	fs.StringVar(&forward, "forward", "", "可选; 收到事件后转发到本地地址 (按目标路径重新签名)")
+	fs.Parse(args)
+
+	if secret == "" {
+		fmt.Println("请输入secret!")
+		os.Exit(2)
+	}
+
+	events, err := webhook.OpenEventLog(storePath)
+	if err != nil {
+		fmt.Println("----- 打开事件文件失败: " + err.Error())
+		os.Exit(1)
+	}
+	defer events.Close()
+
+	mu := &sync.Mutex{}
+	receiver := &webhook.Receiver{
+		Secret: secret,
+		Apikey: apikey,
+		Handler: func(e webhook.Event) error {
+			mu.Lock()
+			printEvent(e)
+			mu.Unlock()
+
+			if err := events.Append(e); err != nil {
+				return err
+			}
+			if forward != "" {
+				if err := webhook.Deliver(context.Background(), nil, forward, secret, e); err != nil {
+					fmt.Println("----- 转发失败: " + err.Error())
+				}
+			}
+			return nil
+		},
+		Observer: rejectPrinter{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, receiver)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Printf(">>> 开始接收webhook事件: http://%s%s (事件文件: %s)\n", displayAddr(addr), path, storePath)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fmt.Println("----- " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("<<< 已停止。")
+}
+
+// redeliver	将事件文件中的事件重新推送到本地地址
+func redeliver(args []string) {
+	var (
+		secret, storePath, target, eventID string
+	)
+
+	fs := flag.NewFlagSet("redeliver", flag.ExitOnError)
+	fs.StringVar(&secret, "secret", os.Getenv("LALAMOVE_SECRET"), "secret; 不为空时按目标路径重新签名")
+	fs.StringVar(&storePath, "store", "webhook-events.jsonl", "事件文件 (.jsonl)")
+	fs.StringVar(&target, "url", "", "推送地址")
+	fs.StringVar(&eventID, "event", "", "可选; 只推送指定事件ID")
+	fs.Parse(args)
+
+	if target == "" {
+		fmt.Println("请输入推送地址!")
+		os.Exit(2)
+	}
+
+	events, err := webhook.ReadEvents(storePath)
+	if err != nil {
+		fmt.Println("----- 读取事件文件失败: " + err.Error())
+		os.Exit(1)
+	}
+
+	sent, failed := 0, 0
+	for _, e := range events {
+		if eventID != "" && e.EventID != eventID {
+			continue
+		}
+		if err := webhook.Deliver(context.Background(), nil, target, secret, e); err != nil {
+			failed++
+			fmt.Println("----- " + err.Error())
+			continue
+		}
+		sent++
+		fmt.Printf("----- [%s] %s 已推送\n", e.EventID, e.EventType)
+	}
+	fmt.Printf("<<< 成功 %d 个, 失败 %d 个。\n", sent, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// rejectPrinter	打印被拒绝的请求
+type rejectPrinter struct{}
+
+func (rejectPrinter) Received(eventType string) {}
+func (rejectPrinter) Rejected(reason string) {
+	fmt.Printf("[%s] 已拒绝: %s\n", time.Now().Format("15:04:05"), reason)
+}
+
+// printEvent	格式化打印事件
+func printEvent(e webhook.Event) {
+	fmt.Printf("\n[%s] %s  eventId=%s\n", e.Time().Format("2006-01-02 15:04:05"), e.EventType, e.EventID)
+
+	if data, err := e.Decode(); err == nil {
+		summary := make([]string, 0)
+		if data.Order != nil {
+			summary = append(summary, "orderId="+data.Order.ID)
+			if data.Order.Status != "" {
+				status := data.Order.Status
+				if data.Order.PreviousStatus != "" {
+					status = data.Order.PreviousStatus + " -> " + status
+				}
+				summary = append(summary, "status="+status)
+			}
+		}
+		if data.PrevOrderID != "" {
+			summary = append(summary, "prevOrderId="+data.PrevOrderID)
+		}
+		if data.Driver != nil {
+			summary = append(summary, "driverId="+data.Driver.ID)
+		}
+		if data.Balance != nil {
+			summary = append(summary, "balance="+data.Balance.Amount+" "+data.Balance.Currency)
+		}
+		if len(summary) > 0 {
+			fmt.Println("  " + strings.Join(summary, "  "))
+		}
+	}
+
+	indented := &bytes.Buffer{}
+	if err := json.Indent(indented, e.Data, "  ", "  "); err != nil {
+		fmt.Println("  " + string(e.Data))
+		return
+	}
+	fmt.Println("  " + indented.String())
+}
+
+// displayAddr	显示用的监听地址
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,233 @@
+// Package escalation	小费自动加价策略; 订单长时间未被司机接单时按配置逐步添加小费
+package escalation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+// Client	订单查询及添加小费接口 (*lalamove.Client 已实现)
+type Client interface {
+	GetOrderDetail(orderID string) (*order.OrderDetail, error)
+	AddPriorityFee(orderID, fee string) (*order.OrderDetail, error)
+}
+
+// Step	加价步骤; 下单 After 时长后仍未接单时添加 Fee
+type Step struct {
+	After time.Duration
+	Fee string
+}
+
+// Policy	加价策略
+type Policy struct {
+	Steps []Step
+	// 各币种 (如 HKD) 累计添加小费上限; 未配置的币种不设上限
+	Caps map[string]float64
+	// 订单状态查询间隔; 默认 30 秒
+	Interval time.Duration
+}
+
+// Escalation	加价记录
+type Escalation struct {
+	OrderID string `json:"orderId"`
+	Step int `json:"step"`
+	Fee string `json:"fee"`
+	// 累计小费 (含重启前已添加的小费)
+	Total float64 `json:"total"`
+	Currency string `json:"currency"`
+	Time time.Time `json:"time"`
+}
+
+// Escalator	加价执行器
+type Escalator struct {
+	Client Client
+	Policy Policy
+
+	// 可选; 记录每次加价事件
+	Store store.OrderStore
+	// 可选; 每次加价后回调
+	OnEscalate func(e Escalation)
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+	// 可选; 等待查询间隔 (用于测试), 默认 time.After
+	After func(d time.Duration) <-chan time.Time
+}
+
+// Watch	监控订单并按策略加价, 直到司机接单、订单结束、所有步骤执行完毕或 ctx 取消.
+// placedAt 为下单时间; 返回本次执行的加价记录. 重启后再次调用时, 根据 Store 中的加价记录
+// 及订单当前小费跳过已执行的步骤, 上限 (Caps) 按累计小费计算
+func (e *Escalator) Watch(ctx context.Context, orderID string, placedAt time.Time) ([]Escalation, error) {
+	if e.Client == nil {
+		return nil, errors.New("escalation: client is required")
+	}
+	steps, err := e.Policy.sortedSteps()
+	if err != nil {
+		return nil, err
+	}
+
+	interval := e.Policy.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	var (
+		next int
+		total float64
+		resumed bool
+		escalations = make([]Escalation, 0)
+	)
+	for next < len(steps) {
+		od, err := e.Client.GetOrderDetail(orderID)
+		if err != nil {
+			return escalations, err
+		}
+		// 已接单或订单已结束
+		if od.Status != enum.ORDER_STATUS_ASSIGN {
+			return escalations, nil
+		}
+		if !resumed {
+			next, total = e.resume(orderID, od, steps)
+			resumed = true
+			if next >= len(steps) {
+				break
+			}
+		}
+
+		elapsed := e.now().Sub(placedAt)
+		for next < len(steps) && steps[next].After <= elapsed {
+			step := steps[next]
+			next++
+
+			fee, _ := strconv.ParseFloat(step.Fee, 64)
+			currency := od.PriceBreakdown.Currency
+			if limit, ok := e.Policy.Caps[currency]; ok && total+fee > limit {
+				// 已达上限, 不再加价
+				return escalations, nil
+			}
+
+			if _, err = e.Client.AddPriorityFee(orderID, step.Fee); err != nil {
+				return escalations, err
+			}
+			total += fee
+
+			esc := Escalation{
+				OrderID: orderID,
+				Step: next,
+				Fee: step.Fee,
+				Total: total,
+				Currency: currency,
+				Time: e.now(),
+			}
+			escalations = append(escalations, esc)
+			e.record(esc)
+		}
+		if next >= len(steps) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return escalations, ctx.Err()
+		case <-e.after(interval):
+		}
+	}
+	return escalations, nil
+}
+
+// resume	根据已记录的加价事件及订单当前小费, 返回已执行的步骤数及累计小费
+func (e *Escalator) resume(orderID string, od *order.OrderDetail, steps []Step) (next int, total float64) {
+	if e.Store != nil {
+		events, _ := e.Store.Events(orderID)
+		for _, ev := range events {
+			if ev.Type != store.EVENT_ESCALATION {
+				continue
+			}
+			if step, err := strconv.Atoi(ev.Data["step"]); err == nil && step > next {
+				next = step
+			}
+			if t, err := strconv.ParseFloat(ev.Data["total"], 64); err == nil && t > total {
+				total = t
+			}
+		}
+	}
+	// 订单当前小费 (含未记录或手动添加的小费)
+	if fee, err := strconv.ParseFloat(od.PriorityFee, 64); err == nil && fee > total {
+		total = fee
+	}
+	// 跳过累计金额已被当前小费覆盖的步骤
+	sum := 0.0
+	for i, step := range steps {
+		fee, _ := strconv.ParseFloat(step.Fee, 64)
+		if sum += fee; sum > total {
+			break
+		}
+		if i+1 > next {
+			next = i + 1
+		}
+	}
+	if next > len(steps) {
+		next = len(steps)
+	}
+	return next, total
+}
+
+// record	记录加价事件
+func (e *Escalator) record(esc Escalation) {
+	if e.OnEscalate != nil {
+		e.OnEscalate(esc)
+	}
+	if e.Store == nil {
+		return
+	}
+	e.Store.Record(store.Event{
+		Type: store.EVENT_ESCALATION,
+		OrderID: esc.OrderID,
+		Time: esc.Time,
+		Data: map[string]string{
+			"step": strconv.Itoa(esc.Step),
+			"priorityFee": esc.Fee,
+			"total": strconv.FormatFloat(esc.Total, 'f', -1, 64),
+			"currency": esc.Currency,
+		},
+	})
+}
+
+func (e *Escalator) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
+
+func (e *Escalator) after(d time.Duration) <-chan time.Time {
+	if e.After != nil {
+		return e.After(d)
+	}
+	return time.After(d)
+}
+
+// sortedSteps	校验并按时长排序加价步骤
+func (p Policy) sortedSteps() ([]Step, error) {
+	if len(p.Steps) == 0 {
+		return nil, errors.New("escalation: policy has no steps")
+	}
+	steps := make([]Step, len(p.Steps))
+	copy(steps, p.Steps)
+	for i, step := range steps {
+		if fee, err := strconv.ParseFloat(step.Fee, 64); err != nil || fee <= 0 {
+			return nil, fmt.Errorf("escalation: step %d has invalid fee %q", i+1, step.Fee)
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].After < steps[j].After
+	})
+	return steps, nil
+}
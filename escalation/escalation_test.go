@@ -0,0 +1,167 @@
+package escalation
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+// fakeClient	每次查询订单时虚拟时钟前进1分钟; 添加小费 assignAfter 次后司机接单
+type fakeClient struct {
+	now time.Time
+	fees []string
+	assignAfter int
+	// 订单当前小费
+	priorityFee float64
+}
+
+func (f *fakeClient) GetOrderDetail(orderID string) (*order.OrderDetail, error) {
+	f.now = f.now.Add(time.Minute)
+	status := enum.ORDER_STATUS_ASSIGN
+	if f.assignAfter > 0 && len(f.fees) >= f.assignAfter {
+		status = enum.ORDER_STATUS_GOING
+	}
+	return &order.OrderDetail{
+		ID: orderID,
+		Status: status,
+		PriorityFee: strconv.FormatFloat(f.priorityFee, 'f', -1, 64),
+		PriceBreakdown: quotation.PriceBreakdown{Currency: "HKD"},
+	}, nil
+}
+
+func (f *fakeClient) AddPriorityFee(orderID, fee string) (*order.OrderDetail, error) {
+	f.fees = append(f.fees, fee)
+	v, _ := strconv.ParseFloat(fee, 64)
+	f.priorityFee += v
+	return &order.OrderDetail{ID: orderID}, nil
+}
+
+func newEscalator(client *fakeClient, caps map[string]float64) *Escalator {
+	return &Escalator{
+		Client: client,
+		Policy: Policy{
+			Steps: []Step{
+				{After: 10 * time.Minute, Fee: "20"},
+				{After: 5 * time.Minute, Fee: "10"},
+				{After: 15 * time.Minute, Fee: "30"},
+			},
+			Caps: caps,
+			Interval: time.Millisecond,
+		},
+		Now: func() time.Time { return client.now },
+		// 不实际等待; 虚拟时钟由 GetOrderDetail 推进
+		After: func(d time.Duration) <-chan time.Time {
+			ch := make(chan time.Time, 1)
+			ch <- client.now
+			return ch
+		},
+	}
+}
+
+func TestWatchAllSteps(t *testing.T) {
+	placedAt := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	client := &fakeClient{now: placedAt}
+	s := store.NewMemoryStore()
+	e := newEscalator(client, nil)
+	e.Store = s
+
+	escalations, err := e.Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10", "20", "30"}, client.fees)
+	if assert.Len(t, escalations, 3) {
+		assert.Equal(t, 1, escalations[0].Step)
+		assert.Equal(t, float64(60), escalations[2].Total)
+		assert.Equal(t, "HKD", escalations[2].Currency)
+	}
+
+	events, _ := s.Events("O1")
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, store.EVENT_ESCALATION, events[0].Type)
+		assert.Equal(t, "10", events[0].Data["priorityFee"])
+	}
+}
+
+func TestWatchStopsWhenAssigned(t *testing.T) {
+	placedAt := time.Now()
+	client := &fakeClient{now: placedAt, assignAfter: 1}
+
+	escalations, err := newEscalator(client, nil).Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Len(t, escalations, 1)
+	assert.Equal(t, []string{"10"}, client.fees)
+}
+
+func TestWatchCap(t *testing.T) {
+	placedAt := time.Now()
+	client := &fakeClient{now: placedAt}
+
+	escalations, err := newEscalator(client, map[string]float64{"HKD": 30}).Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Len(t, escalations, 2)
+	assert.Equal(t, []string{"10", "20"}, client.fees)
+}
+
+func TestWatchResumeFromStore(t *testing.T) {
+	placedAt := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	// 重启前已执行前两步
+	client := &fakeClient{now: placedAt.Add(30 * time.Minute), priorityFee: 30}
+	s := store.NewMemoryStore()
+	for i, total := range []string{"10", "30"} {
+		s.Record(store.Event{Type: store.EVENT_ESCALATION, OrderID: "O1", Data: map[string]string{"step": strconv.Itoa(i + 1), "total": total}})
+	}
+	e := newEscalator(client, map[string]float64{"HKD": 60})
+	e.Store = s
+
+	escalations, err := e.Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"30"}, client.fees)
+	if assert.Len(t, escalations, 1) {
+		assert.Equal(t, 3, escalations[0].Step)
+		assert.Equal(t, float64(60), escalations[0].Total)
+	}
+}
+
+func TestWatchResumeFromPriorityFee(t *testing.T) {
+	placedAt := time.Now()
+	// 无存储记录: 按订单当前小费跳过已覆盖的步骤, 上限按累计小费计算
+	client := &fakeClient{now: placedAt.Add(20 * time.Minute), priorityFee: 30}
+	escalations, err := newEscalator(client, map[string]float64{"HKD": 50}).Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Empty(t, escalations)
+	assert.Empty(t, client.fees)
+
+	client = &fakeClient{now: placedAt.Add(20 * time.Minute), priorityFee: 10}
+	escalations, err = newEscalator(client, nil).Watch(context.Background(), "O1", placedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"20", "30"}, client.fees)
+	assert.Equal(t, float64(60), escalations[1].Total)
+}
+
+func TestWatchCanceled(t *testing.T) {
+	placedAt := time.Now()
+	client := &fakeClient{now: placedAt}
+	e := newEscalator(client, nil)
+	e.Policy.Steps = []Step{{After: time.Hour, Fee: "10"}}
+	e.Policy.Interval = time.Hour
+	e.After = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	escalations, err := e.Watch(ctx, "O1", placedAt)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, escalations)
+}
+
+func TestPolicyInvalidFee(t *testing.T) {
+	e := &Escalator{Client: &fakeClient{}, Policy: Policy{Steps: []Step{{Fee: "abc"}}}}
+	_, err := e.Watch(context.Background(), "O1", time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+// Package forwarder	webhook 事件转发; 已校验的事件先写入持久化发件箱, 再投递到 Sink (至少一次), 失败时按退避间隔重试
+package forwarder
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// Forwarder	事件转发器; 每个 Sink 使用独立的发件箱
+type Forwarder struct {
+	Sink Sink
+	Outbox *Outbox
+
+	// 首次重试间隔; 默认 1 秒, 之后每次失败翻倍
+	Backoff time.Duration
+	// 最大重试间隔; 默认 1 分钟
+	MaxBackoff time.Duration
+	// 可选; 投递失败时回调
+	OnError func(e webhook.Event, attempt int, err error)
+
+	once sync.Once
+	notify chan struct{}
+}
+
+// init	初始化通知通道
+func (f *Forwarder) init() {
+	f.once.Do(func() {
+		f.notify = make(chan struct{}, 1)
+	})
+}
+
+// Handle	写入发件箱; 可作为 webhook.Receiver.Handler (或 webhook.Processor.Handler).
+// 写入失败时返回错误, Lalamove 将重新推送
+func (f *Forwarder) Handle(e webhook.Event) error {
+	f.init()
+	if _, err := f.Outbox.Put(e); err != nil {
+		return err
+	}
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush	按顺序投递发件箱中的事件; 遇到失败即停止以保持顺序, 返回成功投递的数量
+func (f *Forwarder) Flush(ctx context.Context) (int, error) {
+	delivered := 0
+	for _, entry := range f.Outbox.Pending() {
+		if err := ctx.Err(); err != nil {
+			return delivered, err
+		}
+		if err := f.Sink.Publish(ctx, *entry.Event); err != nil {
+			return delivered, &publishError{entry: entry, err: err}
+		}
+		if err := f.Outbox.Ack(entry.Seq); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// Run	持续投递直到 ctx 取消; 失败时按退避间隔重试
+func (f *Forwarder) Run(ctx context.Context) error {
+	f.init()
+
+	attempt := 0
+	for {
+		_, err := f.Flush(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var (
+			timer *time.Timer
+			wait <-chan time.Time
+		)
+		if err != nil {
+			attempt++
+			if pe, ok := err.(*publishError); ok && f.OnError != nil {
+				f.OnError(*pe.entry.Event, attempt, pe.err)
+			}
+			timer = time.NewTimer(f.backoff(attempt))
+			wait = timer.C
+		} else {
+			attempt = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		case <-f.notify:
+			// 失败重试期间收到新事件时仍需等待退避结束
+			if wait != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-wait:
+				}
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// backoff	第 attempt 次失败后的重试间隔
+func (f *Forwarder) backoff(attempt int) time.Duration {
+	d := f.Backoff
+	if d <= 0 {
+		d = time.Second
+	}
+	max := f.MaxBackoff
+	if max <= 0 {
+		max = time.Minute
+	}
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// publishError	投递失败
+type publishError struct {
+	entry Entry
+	err error
+}
+
+func (e *publishError) Error() string {
+	return "forwarder: publish " + e.entry.Event.EventID + ": " + e.err.Error()
+}
+
+func (e *publishError) Unwrap() error {
+	return e.err
+}
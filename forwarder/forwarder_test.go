@@ -0,0 +1,231 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+func event(id string) webhook.Event {
+	return webhook.Event{
+		EventID: id,
+		EventType: webhook.EVENT_ORDER_STATUS_CHANGED,
+		Data: json.RawMessage(`{"order":{"orderId":"O1","status":"ON_GOING"}}`),
+	}
+}
+
+// flakySink	前 failures 次投递失败
+type flakySink struct {
+	mu sync.Mutex
+	failures int
+	published []string
+}
+
+func (s *flakySink) Publish(ctx context.Context, e webhook.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("queue unavailable")
+	}
+	s.published = append(s.published, e.EventID)
+	return nil
+}
+
+func (s *flakySink) Published() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.published...)
+}
+
+func TestOutboxSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	sink := &flakySink{failures: 1}
+
+	outbox, err := OpenOutbox(path)
+	assert.NoError(t, err)
+	f := &Forwarder{Sink: sink, Outbox: outbox}
+	assert.NoError(t, f.Handle(event("E1")))
+	assert.NoError(t, f.Handle(event("E2")))
+
+	// 队列不可用时保留在发件箱中
+	n, err := f.Flush(context.Background())
+	assert.Equal(t, 0, n)
+	assert.ErrorContains(t, err, "queue unavailable")
+	assert.NoError(t, outbox.Close())
+
+	// 重启后继续投递
+	outbox, err = OpenOutbox(path)
+	assert.NoError(t, err)
+	defer outbox.Close()
+	assert.Len(t, outbox.Pending(), 2)
+	f = &Forwarder{Sink: sink, Outbox: outbox}
+	n, err = f.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"E1", "E2"}, sink.Published())
+	assert.Empty(t, outbox.Pending())
+
+	// 序号在重启后继续递增
+	seq, err := outbox.Put(event("E3"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), seq)
+}
+
+func TestOutboxPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	outbox, err := OpenOutbox(path)
+	assert.NoError(t, err)
+	_, err = outbox.Put(event("E1"))
+	assert.NoError(t, err)
+	assert.NoError(t, outbox.Close())
+
+	// 写入中断留下不完整的记录
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0664)
+	assert.NoError(t, err)
+	file.WriteString(`{"seq":2,"event":{"eventId":"E2"`)
+	file.Close()
+
+	outbox, err = OpenOutbox(path)
+	assert.NoError(t, err)
+	if assert.Len(t, outbox.Pending(), 1) {
+		assert.Equal(t, "E1", outbox.Pending()[0].Event.EventID)
+	}
+	seq, err := outbox.Put(event("E2"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), seq)
+	assert.NoError(t, outbox.Close())
+
+	// 压缩后重新打开正常
+	outbox, err = OpenOutbox(path)
+	assert.NoError(t, err)
+	assert.Len(t, outbox.Pending(), 2)
+	assert.NoError(t, outbox.Close())
+
+	// 中间损坏的记录
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, append([]byte("{\"seq\":\n"), data...), 0664))
+	_, err = OpenOutbox(path)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestOutboxCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	outbox, err := OpenOutbox(path)
+	assert.NoError(t, err)
+	_, err = outbox.Put(event("E0"))
+	assert.NoError(t, err)
+
+	// 运行中已确认的记录过多时压缩
+	for i := 1; i <= 600; i++ {
+		seq, err := outbox.Put(event(fmt.Sprintf("E%d", i)))
+		assert.NoError(t, err)
+		assert.NoError(t, outbox.Ack(seq))
+	}
+	_, err = outbox.Put(event("E601"))
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Less(t, strings.Count(string(data), "\n"), 1024)
+	assert.NoError(t, outbox.Close())
+
+	outbox, err = OpenOutbox(path)
+	assert.NoError(t, err)
+	defer outbox.Close()
+	if assert.Len(t, outbox.Pending(), 2) {
+		assert.Equal(t, "E0", outbox.Pending()[0].Event.EventID)
+		assert.Equal(t, "E601", outbox.Pending()[1].Event.EventID)
+	}
+	seq, err := outbox.Put(event("E602"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(603), seq)
+}
+
+func TestRunRetries(t *testing.T) {
+	outbox, err := OpenOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	assert.NoError(t, err)
+	defer outbox.Close()
+
+	sink := &flakySink{failures: 2}
+	attempts := make([]int, 0)
+	f := &Forwarder{
+		Sink: sink,
+		Outbox: outbox,
+		Backoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+		OnError: func(e webhook.Event, attempt int, err error) { attempts = append(attempts, attempt) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- f.Run(ctx) }()
+
+	assert.NoError(t, f.Handle(event("E1")))
+	assert.NoError(t, f.Handle(event("E2")))
+	assert.Eventually(t, func() bool { return len(sink.Published()) == 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Equal(t, []string{"E1", "E2"}, sink.Published())
+	assert.Equal(t, []int{1, 2}, attempts)
+	assert.Empty(t, outbox.Pending())
+}
+
+func TestBackoff(t *testing.T) {
+	f := &Forwarder{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, f.backoff(1))
+	assert.Equal(t, 4*time.Second, f.backoff(3))
+	assert.Equal(t, 5*time.Second, f.backoff(10))
+}
+
+func TestSinks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, NewWriterSink(buf).Publish(context.Background(), event("E1")))
+	assert.True(t, strings.HasPrefix(buf.String(), `{"apiKey":"","timestamp":0,"signature":"","eventId":"E1"`))
+
+	path := filepath.Join(t.TempDir(), "spool.jsonl")
+	file, err := OpenFileSink(path)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Publish(context.Background(), event("E1")))
+	assert.NoError(t, file.Publish(context.Background(), event("E2")))
+	assert.NoError(t, file.Close())
+	events, err := webhook.ReadEvents(path)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	var (
+		received string
+		key string
+	)
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		received = r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &HTTPSink{URL: srv.URL, Header: http.Header{"Authorization": []string{"Bearer token"}}}
+	assert.NoError(t, sink.Publish(context.Background(), event("E1")))
+	assert.Equal(t, "E1", key)
+	assert.Equal(t, "Bearer token", received)
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, sink.Publish(context.Background(), event("E2")))
+}
@@ -0,0 +1,216 @@
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// Entry	发件箱记录; 未确认的事件为 Acked=false
+type Entry struct {
+	Seq int64 `json:"seq"`
+	Event *webhook.Event `json:"event,omitempty"`
+	Acked bool `json:"acked,omitempty"`
+}
+
+// Outbox	持久化发件箱; 记录以 jsonl 格式追加写入文件, 打开时回放未确认的事件并压缩文件,
+// 运行中已确认的记录过多时也会压缩
+type Outbox struct {
+	mu sync.Mutex
+	path string
+	file *os.File
+	seq int64
+	pending []Entry
+	// 上次压缩后追加的记录数
+	written int
+}
+
+// OpenOutbox	打开 (或创建) 发件箱
+func OpenOutbox(path string) (*Outbox, error) {
+	o := &Outbox{path: path}
+	if err := o.replay(); err != nil {
+		return nil, err
+	}
+	if err := o.compact(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// replay	回放记录; 末尾不完整的记录被忽略, 中间损坏的记录返回错误
+func (o *Outbox) replay() error {
+	file, err := os.Open(o.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries := make(map[int64]Entry)
+	order := make([]int64, 0)
+	reader := bufio.NewReader(file)
+	line, badLine := 0, 0
+	var badErr error
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			if text := bytes.TrimSpace(data); len(text) > 0 {
+				if badErr != nil {
+					// 损坏的记录之后仍有记录, 不是写入中断
+					return fmt.Errorf("forwarder: %s line %d: %w", o.path, badLine, badErr)
+				}
+				entry := Entry{}
+				if jerr := json.Unmarshal(text, &entry); jerr != nil {
+					// 末尾不完整的记录 (写入中断) 忽略, 压缩时丢弃
+					badLine, badErr = line, jerr
+				} else {
+					if entry.Seq > o.seq {
+						o.seq = entry.Seq
+					}
+					if entry.Acked {
+						delete(entries, entry.Seq)
+					} else {
+						if _, ok := entries[entry.Seq]; !ok {
+							order = append(order, entry.Seq)
+						}
+						entries[entry.Seq] = entry
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, seq := range order {
+		if entry, ok := entries[seq]; ok {
+			o.pending = append(o.pending, entry)
+		}
+	}
+	return nil
+}
+
+// compact	只保留未确认的事件并重新打开文件 (调用方需持有锁)
+func (o *Outbox) compact() error {
+	if o.file != nil {
+		if err := o.file.Close(); err != nil {
+			return err
+		}
+		o.file = nil
+	}
+
+	tmp := o.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, entry := range o.pending {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+
+	o.file, err = os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	o.written = 0
+	return err
+}
+
+// write	追加记录并同步到磁盘 (调用方需持有锁)
+func (o *Outbox) write(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = o.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := o.file.Sync(); err != nil {
+		return err
+	}
+	o.written++
+	return nil
+}
+
+// compactIfNeeded	已确认的记录占多数时压缩文件 (调用方需持有锁, 且已更新 pending)
+func (o *Outbox) compactIfNeeded() error {
+	if o.written > 1024 && o.written > 2*len(o.pending) {
+		return o.compact()
+	}
+	return nil
+}
+
+// Put	写入事件; 返回记录序号
+func (o *Outbox) Put(e webhook.Event) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry := Entry{Seq: o.seq + 1, Event: &e}
+	if err := o.write(entry); err != nil {
+		return 0, err
+	}
+	o.seq = entry.Seq
+	o.pending = append(o.pending, entry)
+	// 压缩失败时事件已写入, 仍返回序号
+	return entry.Seq, o.compactIfNeeded()
+}
+
+// Ack	确认事件已投递
+func (o *Outbox) Ack(seq int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, entry := range o.pending {
+		if entry.Seq != seq {
+			continue
+		}
+		if err := o.write(Entry{Seq: seq, Acked: true}); err != nil {
+			return err
+		}
+		o.pending = append(o.pending[:i:i], o.pending[i+1:]...)
+		return o.compactIfNeeded()
+	}
+	return nil
+}
+
+// Pending	未确认的事件 (按写入顺序)
+func (o *Outbox) Pending() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Entry{}, o.pending...)
+}
+
+// Close	关闭文件
+func (o *Outbox) Close() error {
+	return o.file.Close()
+}
@@ -0,0 +1,130 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// Sink	事件投递目标 (如内部消息队列); 返回错误时事件保留在发件箱中稍后重试
+type Sink interface {
+	Publish(ctx context.Context, e webhook.Event) error
+}
+
+// SinkFunc	函数形式的 Sink
+type SinkFunc func(ctx context.Context, e webhook.Event) error
+
+// Publish	实现 Sink
+func (f SinkFunc) Publish(ctx context.Context, e webhook.Event) error {
+	return f(ctx, e)
+}
+
+// WriterSink	以 jsonl 格式写入 io.Writer
+type WriterSink struct {
+	mu sync.Mutex
+	w io.Writer
+}
+
+// NewWriterSink	创建 WriterSink
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink	输出到标准输出
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Publish	写入一行事件
+func (s *WriterSink) Publish(ctx context.Context, e webhook.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// FileSink	本地 jsonl 文件 (供其他进程读取的队列目录/文件)
+type FileSink struct {
+	*WriterSink
+
+	file *os.File
+}
+
+// OpenFileSink	打开 (或创建) 文件
+func OpenFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{WriterSink: NewWriterSink(file), file: file}, nil
+}
+
+// Publish	写入事件并同步到磁盘
+func (s *FileSink) Publish(ctx context.Context, e webhook.Event) error {
+	if err := s.WriterSink.Publish(ctx, e); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close	关闭文件
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// HTTPSink	以 POST 请求投递事件 (请求体为事件 json); 响应非 2xx 时视为失败
+type HTTPSink struct {
+	URL string
+	// 可选; 附加请求头 (如认证信息)
+	Header http.Header
+	// 可选; 默认 http.DefaultClient
+	Client *http.Client
+}
+
+// Publish	投递事件
+func (s *HTTPSink) Publish(ctx context.Context, e webhook.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.EventID != "" {
+		// 便于下游去重
+		req.Header.Set("Idempotency-Key", e.EventID)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("forwarder: %s responded %s", s.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// 默认缓存数量上限
+const CACHE_MAX_ENTRIES = 10000
+
+// cacheEntry	缓存记录
+type cacheEntry struct {
+	coords quotation.Coordinates
+	address string
+	// 查询不到
+	missing bool
+	expires time.Time
+}
+
+// Cache	缓存 Geocoder 查询结果 (含查询不到的结果), 减少外部服务调用; 可并发调用
+type Cache struct {
+	Geocoder Geocoder
+	// 缓存有效期; 为零表示不过期
+	TTL time.Duration
+	// 缓存数量上限; 为零时使用 CACHE_MAX_ENTRIES, 超出时淘汰最早写入的记录
+	MaxEntries int
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+
+	mu sync.Mutex
+	entries map[string]cacheEntry
+	keys []string
+	hits int
+	misses int
+}
+
+// NewCache	创建缓存
+func NewCache(g Geocoder, ttl time.Duration) *Cache {
+	return &Cache{Geocoder: g, TTL: ttl}
+}
+
+// now	当前时间
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// Geocode	实现 Geocoder
+func (c *Cache) Geocode(ctx context.Context, address string) (quotation.Coordinates, error) {
+	key := "g:" + normalize(address)
+	if e, ok := c.get(key); ok {
+		if e.missing {
+			return quotation.Coordinates{}, fmt.Errorf("%w: %q (cached)", ErrNotFound, address)
+		}
+		return e.coords, nil
+	}
+	coords, err := c.Geocoder.Geocode(ctx, address)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.put(key, cacheEntry{missing: true})
+		}
+		return coords, err
+	}
+	c.put(key, cacheEntry{coords: coords})
+	return coords, nil
+}
+
+// Reverse	实现 Geocoder
+func (c *Cache) Reverse(ctx context.Context, coords quotation.Coordinates) (string, error) {
+	key := "r:" + reverseKey(coords)
+	if e, ok := c.get(key); ok {
+		if e.missing {
+			return "", fmt.Errorf("%w: %s,%s (cached)", ErrNotFound, coords.Lat, coords.Lng)
+		}
+		return e.address, nil
+	}
+	address, err := c.Geocoder.Reverse(ctx, coords)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.put(key, cacheEntry{missing: true})
+		}
+		return address, err
+	}
+	c.put(key, cacheEntry{address: address})
+	return address, nil
+}
+
+// Stats	缓存命中及未命中次数
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Purge	清空缓存
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.keys = nil, nil
+}
+
+// get	读取未过期的缓存
+func (c *Cache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if ok && (e.expires.IsZero() || c.now().Before(e.expires)) {
+		c.hits++
+		return e, true
+	}
+	c.misses++
+	return cacheEntry{}, false
+}
+
+// put	写入缓存
+func (c *Cache) put(key string, e cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+	if c.TTL > 0 {
+		e.expires = c.now().Add(c.TTL)
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = e
+
+	max := c.MaxEntries
+	if max <= 0 {
+		max = CACHE_MAX_ENTRIES
+	}
+	for len(c.keys) > max {
+		delete(c.entries, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+}
+
+// reverseKey	反查缓存键 (6 位小数, 约 0.1 米)
+func reverseKey(coords quotation.Coordinates) string {
+	lat, lng, err := coords.Float()
+	if err != nil {
+		return coords.Lat + "," + coords.Lng
+	}
+	return strconv.FormatFloat(lat, 'f', 6, 64) + "," + strconv.FormatFloat(lng, 'f', 6, 64)
+}
@@ -0,0 +1,118 @@
+// Package geocode	地址与坐标互查; 为只有地址 (或只有坐标) 的站点补全信息, 以便仅凭地址构建报价单
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// ErrNotFound	找不到地址或坐标
+var ErrNotFound = errors.New("geocode: not found")
+
+// Geocoder	地址解析接口; 实现需可并发调用
+type Geocoder interface {
+	// Geocode	地址转坐标
+	Geocode(ctx context.Context, address string) (quotation.Coordinates, error)
+	// Reverse	坐标转地址
+	Reverse(ctx context.Context, coords quotation.Coordinates) (string, error)
+}
+
+// normalize	地址查找键: 忽略大小写及多余空白
+func normalize(address string) string {
+	return strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
+
+// coordinates	格式化坐标 (最多 7 位小数, 约 1 厘米)
+func coordinates(lat, lng float64) quotation.Coordinates {
+	format := func(v float64) string {
+		s := strconv.FormatFloat(v, 'f', 7, 64)
+		s = strings.TrimRight(s, "0")
+		return strings.TrimSuffix(s, ".")
+	}
+	return quotation.Coordinates{Lat: format(lat), Lng: format(lng)}
+}
+
+// StopBuilder	使用 Geocoder 补全站点的坐标或地址
+type StopBuilder struct {
+	Geocoder Geocoder
+}
+
+// NewStopBuilder	创建 StopBuilder
+func NewStopBuilder(g Geocoder) *StopBuilder {
+	return &StopBuilder{Geocoder: g}
+}
+
+// Stop	根据地址创建站点
+func (b *StopBuilder) Stop(ctx context.Context, address string) (quotation.DeliveryStop, error) {
+	return b.Complete(ctx, quotation.DeliveryStop{Address: address})
+}
+
+// Complete	补全站点: 缺少坐标时按地址查询, 缺少地址时按坐标反查; 两者均已填写时不做修改
+func (b *StopBuilder) Complete(ctx context.Context, stop quotation.DeliveryStop) (quotation.DeliveryStop, error) {
+	hasAddress := strings.TrimSpace(stop.Address) != ""
+	hasCoords := stop.Coordinates.Lat != "" && stop.Coordinates.Lng != ""
+	switch {
+	case hasAddress && hasCoords:
+	case hasAddress:
+		coords, err := b.Geocoder.Geocode(ctx, stop.Address)
+		if err != nil {
+			return stop, fmt.Errorf("geocode %q: %w", stop.Address, err)
+		}
+		stop.Coordinates = coords
+	case hasCoords:
+		if _, _, err := stop.Coordinates.Float(); err != nil {
+			return stop, fmt.Errorf("invalid coordinates %s,%s: %w", stop.Coordinates.Lat, stop.Coordinates.Lng, err)
+		}
+		address, err := b.Geocoder.Reverse(ctx, stop.Coordinates)
+		if err != nil {
+			return stop, fmt.Errorf("reverse geocode %s,%s: %w", stop.Coordinates.Lat, stop.Coordinates.Lng, err)
+		}
+		stop.Address = address
+	default:
+		return stop, errors.New("stop requires an address or coordinates")
+	}
+	return stop, nil
+}
+
+// Stops	根据地址创建多个站点 (首个为取货点)
+func (b *StopBuilder) Stops(ctx context.Context, addresses ...string) ([]quotation.DeliveryStop, error) {
+	stops := make([]quotation.DeliveryStop, 0, len(addresses))
+	for i, address := range addresses {
+		stop, err := b.Stop(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("stop %d: %w", i, err)
+		}
+		stops = append(stops, stop)
+	}
+	return stops, nil
+}
+
+// Build	补全报价单中所有站点
+func (b *StopBuilder) Build(ctx context.Context, q *quotation.Quotation) error {
+	for i := range q.Stops {
+		stop, err := b.Complete(ctx, q.Stops[i])
+		if err != nil {
+			return fmt.Errorf("stop %d: %w", i, err)
+		}
+		q.Stops[i] = stop
+	}
+	return nil
+}
+
+// Quotation	仅凭地址构建报价单 (首个地址为取货点)
+func (b *StopBuilder) Quotation(ctx context.Context, serviceType, language string, addresses ...string) (*quotation.Quotation, error) {
+	stops, err := b.Stops(ctx, addresses...)
+	if err != nil {
+		return nil, err
+	}
+	q := &quotation.Quotation{ServiceType: serviceType, Language: language}
+	if err := q.AddStops(stops...); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
@@ -0,0 +1,207 @@
+package geocode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+const table = `address,lat,lng
+"Tsim Sha Tsui Star Ferry, Hong Kong",22.2937,114.1686
+Mong Kok Station,22.3193,114.1694
+`
+
+// countingGeocoder	记录调用次数
+type countingGeocoder struct {
+	Geocoder
+	mu sync.Mutex
+	calls int
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address string) (quotation.Coordinates, error) {
+	g.mu.Lock()
+	g.calls++
+	g.mu.Unlock()
+	return g.Geocoder.Geocode(ctx, address)
+}
+
+func (g *countingGeocoder) Reverse(ctx context.Context, coords quotation.Coordinates) (string, error) {
+	g.mu.Lock()
+	g.calls++
+	g.mu.Unlock()
+	return g.Geocoder.Reverse(ctx, coords)
+}
+
+func TestStaticGeocoder(t *testing.T) {
+	ctx := context.Background()
+	g, err := LoadStaticGeocoder(strings.NewReader(table))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, g.Len())
+
+	coords, err := g.Geocode(ctx, "  mong kok   STATION ")
+	assert.NoError(t, err)
+	assert.Equal(t, quotation.Coordinates{Lat: "22.3193", Lng: "114.1694"}, coords)
+	_, err = g.Geocode(ctx, "Central")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 半径内最近的地址
+	address, err := g.Reverse(ctx, quotation.Coordinates{Lat: "22.2939", Lng: "114.1686"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Tsim Sha Tsui Star Ferry, Hong Kong", address)
+	_, err = g.Reverse(ctx, quotation.Coordinates{Lat: "22.3000", Lng: "114.1686"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = g.Reverse(ctx, quotation.Coordinates{Lat: "x", Lng: "114.1686"})
+	assert.Error(t, err)
+
+	assert.Error(t, g.Add("Bad", quotation.Coordinates{Lat: "22.3", Lng: ""}))
+	_, err = LoadStaticGeocoder(strings.NewReader("A,22.3\n"))
+	assert.Error(t, err)
+	_, err = LoadStaticGeocoder(strings.NewReader("A,22.3,abc\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	static, _ := LoadStaticGeocoder(strings.NewReader(table))
+	inner := &countingGeocoder{Geocoder: static}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(inner, time.Hour)
+	cache.Now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Geocode(ctx, "Mong Kok Station")
+		assert.NoError(t, err)
+		_, err = cache.Geocode(ctx, "Central")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = cache.Reverse(ctx, quotation.Coordinates{Lat: "22.3193", Lng: "114.1694"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, inner.calls)
+	hits, misses := cache.Stats()
+	assert.Equal(t, 6, hits)
+	assert.Equal(t, 3, misses)
+
+	// 过期后重新查询
+	now = now.Add(time.Hour)
+	_, _ = cache.Geocode(ctx, "Mong Kok Station")
+	assert.Equal(t, 4, inner.calls)
+
+	// 超出上限时淘汰最早的记录
+	cache.Purge()
+	cache.MaxEntries = 1
+	_, _ = cache.Geocode(ctx, "Mong Kok Station")
+	_, _ = cache.Geocode(ctx, "Central")
+	_, _ = cache.Geocode(ctx, "Mong Kok Station")
+	assert.Equal(t, 7, inner.calls)
+}
+
+func TestStopBuilder(t *testing.T) {
+	ctx := context.Background()
+	static, _ := LoadStaticGeocoder(strings.NewReader(table))
+	b := NewStopBuilder(NewCache(static, 0))
+
+	q, err := b.Quotation(ctx, enum.SERVICE_TYPE_MOTORCYCLE, enum.LANG_EN_HK, "Tsim Sha Tsui Star Ferry, Hong Kong", "mong kok station")
+	assert.NoError(t, err)
+	assert.Len(t, q.Stops, 2)
+	assert.Equal(t, "22.2937", q.Stops[0].Coordinates.Lat)
+	assert.Equal(t, "mong kok station", q.Stops[1].Address)
+
+	// 只有坐标的站点反查地址; 已完整的站点不变
+	q.Stops = append(q.Stops,
+		quotation.DeliveryStop{Coordinates: quotation.Coordinates{Lat: "22.3193", Lng: "114.1694"}, Name: "Katrina"},
+		quotation.DeliveryStop{Address: "Custom", Coordinates: quotation.Coordinates{Lat: "22.1", Lng: "114.1"}},
+	)
+	assert.NoError(t, b.Build(ctx, q))
+	assert.Equal(t, "Mong Kok Station", q.Stops[2].Address)
+	assert.Equal(t, "Katrina", q.Stops[2].Name)
+	assert.Equal(t, "Custom", q.Stops[3].Address)
+
+	_, err = b.Stops(ctx, "Mong Kok Station", "Central")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, err, "stop 1")
+	_, err = b.Complete(ctx, quotation.DeliveryStop{Name: "Nobody"})
+	assert.Error(t, err)
+}
+
+func TestGoogle(t *testing.T) {
+	var query []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = append(query, r.URL.RawQuery)
+		switch {
+		case r.URL.Query().Get("address") == "Nowhere":
+			w.Write([]byte(`{"status":"ZERO_RESULTS","results":[]}`))
+		case r.URL.Query().Get("address") == "Denied":
+			w.Write([]byte(`{"status":"REQUEST_DENIED","error_message":"invalid key","results":[]}`))
+		case r.URL.Query().Get("address") != "":
+			w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"Mong Kok, Hong Kong","geometry":{"location":{"lat":22.3193039,"lng":114.1693611}}}]}`))
+		default:
+			w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"Mong Kok, Hong Kong","geometry":{"location":{"lat":22.3193,"lng":114.1694}}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	g := NewHTTPGeocoder(&Google{Key: "k", Region: "hk", Endpoint: server.URL})
+	coords, err := g.Geocode(ctx, "Mong Kok")
+	assert.NoError(t, err)
+	assert.Equal(t, quotation.Coordinates{Lat: "22.3193039", Lng: "114.1693611"}, coords)
+	assert.Equal(t, "address=Mong+Kok&key=k&region=hk", query[0])
+
+	address, err := g.Reverse(ctx, quotation.Coordinates{Lat: "22.3193", Lng: "114.1694"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Mong Kok, Hong Kong", address)
+	assert.Contains(t, query[1], "latlng=22.3193%2C114.1694")
+
+	_, err = g.Geocode(ctx, "Nowhere")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = g.Geocode(ctx, "Denied")
+	assert.ErrorContains(t, err, "REQUEST_DENIED invalid key")
+}
+
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "lalamove-go-api test", r.Header.Get("User-Agent"))
+		assert.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+		switch {
+		case r.URL.Path == "/search" && r.URL.Query().Get("q") == "Nowhere":
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/search":
+			assert.Equal(t, "hk", r.URL.Query().Get("countrycodes"))
+			w.Write([]byte(`[{"lat":"22.3193039","lon":"114.1693611","display_name":"Mong Kok"}]`))
+		case r.URL.Path == "/reverse" && r.URL.Query().Get("lat") == "0":
+			w.Write([]byte(`{"error":"Unable to geocode"}`))
+		case r.URL.Path == "/reverse":
+			w.Write([]byte(`{"lat":"22.3193039","lon":"114.1693611","display_name":"Mong Kok, Kowloon, Hong Kong"}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	g := NewHTTPGeocoder(&Nominatim{UserAgent: "lalamove-go-api test", CountryCodes: "hk", Endpoint: server.URL})
+	coords, err := g.Geocode(ctx, "Mong Kok")
+	assert.NoError(t, err)
+	assert.Equal(t, quotation.Coordinates{Lat: "22.3193039", Lng: "114.1693611"}, coords)
+	_, err = g.Geocode(ctx, "Nowhere")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	address, err := g.Reverse(ctx, coords)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mong Kok, Kowloon, Hong Kong", address)
+	_, err = g.Reverse(ctx, quotation.Coordinates{Lat: "0", Lng: "0"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	g.Provider.(*Nominatim).Endpoint = server.URL + "/limited"
+	_, err = g.Geocode(ctx, "Mong Kok")
+	assert.ErrorContains(t, err, "429")
+}
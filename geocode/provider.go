@@ -0,0 +1,286 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// Provider	外部地址解析服务适配器: 构建请求及解析响应; 查询不到时解析方法返回 ErrNotFound
+type Provider interface {
+	GeocodeRequest(ctx context.Context, address string) (*http.Request, error)
+	ParseGeocode(body []byte) (quotation.Coordinates, error)
+	ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error)
+	ParseReverse(body []byte) (string, error)
+}
+
+// HTTPGeocoder	通过 Provider 调用外部服务的 Geocoder
+type HTTPGeocoder struct {
+	Provider Provider
+	// 可选; 默认 http.DefaultClient
+	Client *http.Client
+}
+
+// NewHTTPGeocoder	创建 HTTPGeocoder
+func NewHTTPGeocoder(p Provider) *HTTPGeocoder {
+	return &HTTPGeocoder{Provider: p}
+}
+
+// Geocode	实现 Geocoder
+func (g *HTTPGeocoder) Geocode(ctx context.Context, address string) (quotation.Coordinates, error) {
+	req, err := g.Provider.GeocodeRequest(ctx, address)
+	if err != nil {
+		return quotation.Coordinates{}, err
+	}
+	body, err := g.do(req)
+	if err != nil {
+		return quotation.Coordinates{}, err
+	}
+	return g.Provider.ParseGeocode(body)
+}
+
+// Reverse	实现 Geocoder
+func (g *HTTPGeocoder) Reverse(ctx context.Context, coords quotation.Coordinates) (string, error) {
+	lat, lng, err := coords.Float()
+	if err != nil {
+		return "", fmt.Errorf("geocode: invalid coordinates: %w", err)
+	}
+	req, err := g.Provider.ReverseRequest(ctx, lat, lng)
+	if err != nil {
+		return "", err
+	}
+	body, err := g.do(req)
+	if err != nil {
+		return "", err
+	}
+	return g.Provider.ParseReverse(body)
+}
+
+// do	发送请求; 响应非 2xx 时返回错误
+func (g *HTTPGeocoder) do(req *http.Request) ([]byte, error) {
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("geocode: %s responded %d: %s", req.URL.Host, resp.StatusCode, truncate(string(body), 200))
+	}
+	return body, nil
+}
+
+// truncate	截断过长的响应内容
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// 默认服务地址
+const (
+	GOOGLE_GEOCODE_ENDPOINT = "https://maps.googleapis.com/maps/api/geocode/json"
+	NOMINATIM_ENDPOINT = "https://nominatim.openstreetmap.org"
+)
+
+// Google	Google Maps Geocoding API 适配器
+type Google struct {
+	Key string
+	// 可选; 结果语言, 如 zh-HK
+	Language string
+	// 可选; 优先地区 (ccTLD), 如 hk
+	Region string
+	// 可选; 默认 GOOGLE_GEOCODE_ENDPOINT
+	Endpoint string
+}
+
+// NewGoogleGeocoder	使用 Google Maps Geocoding API 的 Geocoder
+func NewGoogleGeocoder(key string) *HTTPGeocoder {
+	return NewHTTPGeocoder(&Google{Key: key})
+}
+
+// request	构建请求
+func (p *Google) request(ctx context.Context, query url.Values) (*http.Request, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = GOOGLE_GEOCODE_ENDPOINT
+	}
+	query.Set("key", p.Key)
+	if p.Language != "" {
+		query.Set("language", p.Language)
+	}
+	if p.Region != "" {
+		query.Set("region", p.Region)
+	}
+	return http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+}
+
+// GeocodeRequest	实现 Provider
+func (p *Google) GeocodeRequest(ctx context.Context, address string) (*http.Request, error) {
+	return p.request(ctx, url.Values{"address": {address}})
+}
+
+// ReverseRequest	实现 Provider
+func (p *Google) ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error) {
+	return p.request(ctx, url.Values{"latlng": {strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64)}})
+}
+
+// googleResponse	Google 响应
+type googleResponse struct {
+	Status string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Results []struct {
+		FormattedAddress string `json:"formatted_address"`
+		Geometry struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+	} `json:"results"`
+}
+
+// parse	解析响应
+func (p *Google) parse(body []byte) (*googleResponse, error) {
+	resp := &googleResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("geocode: google: %w", err)
+	}
+	switch resp.Status {
+	case "OK":
+		if len(resp.Results) > 0 {
+			return resp, nil
+		}
+		return nil, ErrNotFound
+	case "ZERO_RESULTS":
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("geocode: google: %s %s", resp.Status, resp.ErrorMessage)
+}
+
+// ParseGeocode	实现 Provider
+func (p *Google) ParseGeocode(body []byte) (quotation.Coordinates, error) {
+	resp, err := p.parse(body)
+	if err != nil {
+		return quotation.Coordinates{}, err
+	}
+	location := resp.Results[0].Geometry.Location
+	return coordinates(location.Lat, location.Lng), nil
+}
+
+// ParseReverse	实现 Provider
+func (p *Google) ParseReverse(body []byte) (string, error) {
+	resp, err := p.parse(body)
+	if err != nil {
+		return "", err
+	}
+	return resp.Results[0].FormattedAddress, nil
+}
+
+// Nominatim	OpenStreetMap Nominatim 适配器; 公共服务要求提供可识别的 User-Agent 且每秒最多 1 次请求
+type Nominatim struct {
+	UserAgent string
+	// 可选; 联系邮箱
+	Email string
+	// 可选; 结果语言, 如 zh-HK
+	Language string
+	// 可选; 限定国家 (ISO 3166-1 alpha-2, 逗号分隔), 如 hk
+	CountryCodes string
+	// 可选; 默认 NOMINATIM_ENDPOINT (可指向自建服务)
+	Endpoint string
+}
+
+// NewNominatimGeocoder	使用 Nominatim 的 Geocoder
+func NewNominatimGeocoder(userAgent string) *HTTPGeocoder {
+	return NewHTTPGeocoder(&Nominatim{UserAgent: userAgent})
+}
+
+// request	构建请求
+func (p *Nominatim) request(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = NOMINATIM_ENDPOINT
+	}
+	query.Set("format", "jsonv2")
+	if p.Email != "" {
+		query.Set("email", p.Email)
+	}
+	if p.Language != "" {
+		query.Set("accept-language", p.Language)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+	return req, nil
+}
+
+// GeocodeRequest	实现 Provider
+func (p *Nominatim) GeocodeRequest(ctx context.Context, address string) (*http.Request, error) {
+	query := url.Values{"q": {address}, "limit": {"1"}}
+	if p.CountryCodes != "" {
+		query.Set("countrycodes", p.CountryCodes)
+	}
+	return p.request(ctx, "/search", query)
+}
+
+// ReverseRequest	实现 Provider
+func (p *Nominatim) ReverseRequest(ctx context.Context, lat, lng float64) (*http.Request, error) {
+	return p.request(ctx, "/reverse", url.Values{
+		"lat": {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon": {strconv.FormatFloat(lng, 'f', -1, 64)},
+	})
+}
+
+// nominatimPlace	Nominatim 地点 (坐标为字符串)
+type nominatimPlace struct {
+	Lat string `json:"lat"`
+	Lon string `json:"lon"`
+	DisplayName string `json:"display_name"`
+	Error string `json:"error"`
+}
+
+// ParseGeocode	实现 Provider
+func (p *Nominatim) ParseGeocode(body []byte) (quotation.Coordinates, error) {
+	places := []nominatimPlace{}
+	if err := json.Unmarshal(body, &places); err != nil {
+		return quotation.Coordinates{}, fmt.Errorf("geocode: nominatim: %w", err)
+	}
+	if len(places) == 0 {
+		return quotation.Coordinates{}, ErrNotFound
+	}
+	coords := quotation.Coordinates{Lat: places[0].Lat, Lng: places[0].Lon}
+	if _, _, err := coords.Float(); err != nil {
+		return quotation.Coordinates{}, fmt.Errorf("geocode: nominatim: invalid coordinates: %w", err)
+	}
+	return coords, nil
+}
+
+// ParseReverse	实现 Provider
+func (p *Nominatim) ParseReverse(body []byte) (string, error) {
+	place := nominatimPlace{}
+	if err := json.Unmarshal(body, &place); err != nil {
+		return "", fmt.Errorf("geocode: nominatim: %w", err)
+	}
+	if place.Error != "" || place.DisplayName == "" {
+		return "", ErrNotFound
+	}
+	return place.DisplayName, nil
+}
@@ -0,0 +1,121 @@
+package geocode
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// 静态表反查时坐标相差多少米以内视为同一地点
+const STATIC_REVERSE_RADIUS = 50.0
+
+// staticEntry	静态表记录
+type staticEntry struct {
+	address string
+	coords quotation.Coordinates
+}
+
+// StaticGeocoder	基于静态地址表的 Geocoder (用于测试及离线使用)
+type StaticGeocoder struct {
+	// 反查半径 (米); 为零时使用 STATIC_REVERSE_RADIUS
+	Radius float64
+
+	mu sync.RWMutex
+	entries []staticEntry
+	index map[string]int
+}
+
+// NewStaticGeocoder	创建空的静态地址表
+func NewStaticGeocoder() *StaticGeocoder {
+	return &StaticGeocoder{index: make(map[string]int)}
+}
+
+// Add	添加 (或覆盖) 地址; 坐标格式错误时返回错误
+func (g *StaticGeocoder) Add(address string, coords quotation.Coordinates) error {
+	if _, _, err := coords.Float(); err != nil {
+		return fmt.Errorf("geocode: %q: invalid coordinates: %w", address, err)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry := staticEntry{address: strings.TrimSpace(address), coords: coords}
+	key := normalize(address)
+	if i, ok := g.index[key]; ok {
+		g.entries[i] = entry
+		return nil
+	}
+	g.index[key] = len(g.entries)
+	g.entries = append(g.entries, entry)
+	return nil
+}
+
+// LoadStaticGeocoder	从 csv 读取静态地址表; 每行为 address,lat,lng, 可带表头
+func LoadStaticGeocoder(r io.Reader) (*StaticGeocoder, error) {
+	g := NewStaticGeocoder()
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return g, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("geocode: line %d: %w", line, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		if err := g.Add(record[0], quotation.Coordinates{Lat: record[1], Lng: record[2]}); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Len	地址数量
+func (g *StaticGeocoder) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.entries)
+}
+
+// Geocode	实现 Geocoder
+func (g *StaticGeocoder) Geocode(ctx context.Context, address string) (quotation.Coordinates, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	i, ok := g.index[normalize(address)]
+	if !ok {
+		return quotation.Coordinates{}, fmt.Errorf("%w: %q", ErrNotFound, address)
+	}
+	return g.entries[i].coords, nil
+}
+
+// Reverse	实现 Geocoder; 返回反查半径内最近的地址
+func (g *StaticGeocoder) Reverse(ctx context.Context, coords quotation.Coordinates) (string, error) {
+	if _, _, err := coords.Float(); err != nil {
+		return "", fmt.Errorf("geocode: invalid coordinates: %w", err)
+	}
+	radius := g.Radius
+	if radius <= 0 {
+		radius = STATIC_REVERSE_RADIUS
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	best, bestDistance := -1, math.MaxFloat64
+	for i, e := range g.entries {
+		if d, _ := e.coords.DistanceTo(coords); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	if best < 0 || bestDistance > radius {
+		return "", fmt.Errorf("%w: %s,%s", ErrNotFound, coords.Lat, coords.Lng)
+	}
+	return g.entries[best].address, nil
+}
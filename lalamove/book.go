@@ -0,0 +1,173 @@
+package lalamove
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// BookRequest	一键下单请求
+type BookRequest struct {
+	Quotation *quotation.Quotation
+
+	// 发件人信息 (stopId 自动填充)
+	Sender order.Contact
+	// 收件人信息; 以站点下标 (Quotation.Stops 的下标, 从1开始) 作为键, stopId 自动填充.
+	// 未提供的站点将使用站点本身的 Name/Phone/Remarks
+	Recipients map[int]order.DeliveryDetail
+
+	// optional fields
+	IsRecipientSMSEnabled bool
+	IsPODEnabled bool
+	Partner string
+	Metadata map[string]string
+}
+
+// BookResult	一键下单结果
+type BookResult struct {
+	Quotation *quotation.QuotationDetail
+	Order *order.OrderDetail
+	// 报价单返回的各站点对应的提交站点下标 (路线优化后顺序可能改变)
+	StopOrder []int
+}
+
+// Book	一键报价并下单; 自动将报价单返回的 stopId 对应到发件人及收件人信息.
+// 若下单后校验失败, 将尝试取消已创建的订单
+func (cli *Client) Book(req BookRequest) (*BookResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	qd, err := cli.GetQuotations(req.Quotation)
+	if err != nil {
+		return nil, err
+	}
+	if qd == nil || qd.ID == "" {
+		return nil, errors.New("book: empty quotation returned")
+	}
+	result := &BookResult{Quotation: qd}
+
+	result.StopOrder, err = stopOrder(req.Quotation, qd)
+	if err != nil {
+		return result, fmt.Errorf("book: %w", err)
+	}
+	o, err := req.buildOrder(qd, result.StopOrder)
+	if err != nil {
+		return result, err
+	}
+
+	od, err := cli.PlaceOrder(o)
+	if err != nil {
+		return result, err
+	}
+	if od == nil || od.ID == "" {
+		return result, errors.New("book: empty order returned")
+	}
+	result.Order = od
+
+	// 校验订单; 失败时取消订单
+	if err = checkBookedOrder(qd, od); err != nil {
+		if _, cancelErr := cli.CancelOrder(od.ID); cancelErr != nil {
+			return result, fmt.Errorf("%w (cancel order %s: %s)", err, od.ID, cancelErr.Error())
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// validate	下单请求校验
+func (req BookRequest) validate() error {
+	if req.Quotation == nil {
+		return errors.New("book: quotation is required")
+	}
+	stops := len(req.Quotation.Stops)
+	if stops < enum.QUOT_STOPS_MIN || stops > enum.QUOT_STOPS_MAX {
+		return fmt.Errorf("book: quotation stops must be between %d and %d, got %d", enum.QUOT_STOPS_MIN, enum.QUOT_STOPS_MAX, stops)
+	}
+	for idx := range req.Recipients {
+		if idx < 1 || idx >= stops {
+			return fmt.Errorf("book: recipient stop index %d out of range [1, %d]", idx, stops-1)
+		}
+	}
+	return nil
+}
+
+// stopOrder	报价单返回站点对应的提交站点下标; 未开启路线优化时顺序不变, 取货站点被移动时返回错误
+func stopOrder(q *quotation.Quotation, qd *quotation.QuotationDetail) ([]int, error) {
+	if len(qd.Stops) != len(q.Stops) {
+		return nil, fmt.Errorf("quotation %s returned %d stops, submitted %d", qd.ID, len(qd.Stops), len(q.Stops))
+	}
+	if !q.IsRouteOptimized {
+		order := make([]int, len(qd.Stops))
+		for i := range order {
+			order[i] = i
+		}
+		return order, nil
+	}
+
+	order, err := qd.StopOrder(q.Stops)
+	if err != nil {
+		return nil, fmt.Errorf("quotation %s: %w", qd.ID, err)
+	}
+	if order[0] != 0 {
+		return nil, fmt.Errorf("quotation %s moved the pickup stop", qd.ID)
+	}
+	return order, nil
+}
+
+// buildOrder	根据报价单构建订单; stopOrder 为返回站点对应的提交站点下标
+func (req BookRequest) buildOrder(qd *quotation.QuotationDetail, stopOrder []int) (*order.Order, error) {
+	o := &order.Order{
+		QuotationId: qd.ID,
+		Sender: req.Sender,
+		IsRecipientSMSEnabled: req.IsRecipientSMSEnabled,
+		IsPODEnabled: req.IsPODEnabled,
+		Partner: req.Partner,
+		Metadata: req.Metadata,
+	}
+
+	sender := qd.SenderStop()
+	o.Sender.StopId = sender.ID
+	if o.Sender.Name == "" {
+		o.Sender.Name = req.Quotation.Stops[0].Name
+	}
+	if o.Sender.Phone == "" {
+		o.Sender.Phone = req.Quotation.Stops[0].Phone
+	}
+	if o.Sender.StopId == "" || o.Sender.Name == "" || o.Sender.Phone == "" {
+		return nil, errors.New("book: sender stopId, name and phone are required")
+	}
+
+	for i, stop := range qd.RecipientStops() {
+		idx := stopOrder[i+1]
+		recipient, ok := req.Recipients[idx]
+		if !ok {
+			submitted := req.Quotation.Stops[idx]
+			recipient = order.DeliveryDetail{
+				Name: submitted.Name,
+				Phone: submitted.Phone,
+				Remarks: submitted.Remarks,
+			}
+		}
+		recipient.StopId = stop.ID
+		if recipient.StopId == "" || recipient.Name == "" || recipient.Phone == "" {
+			return nil, fmt.Errorf("book: recipient at stop %d requires stopId, name and phone", idx)
+		}
+		o.AddRecipient(recipient)
+	}
+	return o, nil
+}
+
+// checkBookedOrder	校验已创建的订单与报价单是否一致
+func checkBookedOrder(qd *quotation.QuotationDetail, od *order.OrderDetail) error {
+	if od.QuotationId != "" && od.QuotationId != qd.ID {
+		return fmt.Errorf("book: order %s placed with quotation %s, expected %s", od.ID, od.QuotationId, qd.ID)
+	}
+	if od.Status == enum.ORDER_STATUS_REJECTED || od.Status == enum.ORDER_STATUS_EXPIRED {
+		return fmt.Errorf("book: order %s is %s", od.ID, od.Status)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+func newBookServer(t *testing.T, orderStatus string, placed *order.Order, canceled *bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == METHOD_POST && r.URL.Path == "/v3/quotations":
+			w.Write([]byte(`{"data":{"quotationId":"Q1","stops":[` +
+				`{"stopId":"S0","address":"A0","coordinates":{"lat":"22.1","lng":"114.1"}},` +
+				`{"stopId":"S1","address":"A1","coordinates":{"lat":"22.2","lng":"114.2"}},` +
+				`{"stopId":"S2","address":"A2","coordinates":{"lat":"22.3","lng":"114.3"}}]}}`))
+		case r.Method == METHOD_POST && r.URL.Path == "/v3/orders":
+			data := struct{ Data *order.Order `json:"data"` }{Data: placed}
+			assert.NoError(t, json.Unmarshal(body, &data))
+			w.Write([]byte(`{"data":{"orderId":"O1","quotationId":"Q1","status":"` + orderStatus + `"}}`))
+		case r.Method == METHOD_DELETE && r.URL.Path == "/v3/orders/O1":
+			*canceled = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"id":"ERR_NOT_FOUND","message":"not found"}]}`))
+		}
+	}))
+}
+
+func newBookRequest() BookRequest {
+	q := &quotation.Quotation{
+		ServiceType: enum.SERVICE_TYPE_MOTORCYCLE,
+		Language: enum.LANG_EN_HK,
+	}
+	q.AddStop(quotation.DeliveryStop{Address: "A0"}).
+		AddStop(quotation.DeliveryStop{Address: "A1", Name: "Stop One", Phone: "+85238485761"}).
+		AddStop(quotation.DeliveryStop{Address: "A2"})
+
+	return BookRequest{
+		Quotation: q,
+		Sender: order.Contact{Name: "Michal", Phone: "+85238485765"},
+		Recipients: map[int]order.DeliveryDetail{
+			2: {Name: "Katrina", Phone: "+85238485760", Remarks: "YYYYYY"},
+		},
+	}
+}
+
+func TestBook(t *testing.T) {
+	placed := &order.Order{}
+	canceled := false
+	srv := newBookServer(t, enum.ORDER_STATUS_ASSIGN, placed, &canceled)
+	defer srv.Close()
+
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).SetEndpoint(srv.URL)
+
+	result, err := c.Book(newBookRequest())
+	assert.NoError(t, err)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "Q1", result.Quotation.ID)
+		assert.Equal(t, "O1", result.Order.ID)
+	}
+	assert.False(t, canceled)
+
+	assert.Equal(t, "Q1", placed.QuotationId)
+	assert.Equal(t, "S0", placed.Sender.StopId)
+	if assert.Len(t, placed.Recipients, 2) {
+		assert.Equal(t, "S1", placed.Recipients[0].StopId)
+		assert.Equal(t, "Stop One", placed.Recipients[0].Name)
+		assert.Equal(t, "S2", placed.Recipients[1].StopId)
+		assert.Equal(t, "Katrina", placed.Recipients[1].Name)
+	}
+}
+
+func TestBookCancelOnRejected(t *testing.T) {
+	canceled := false
+	srv := newBookServer(t, enum.ORDER_STATUS_REJECTED, &order.Order{}, &canceled)
+	defer srv.Close()
+
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).SetEndpoint(srv.URL)
+
+	result, err := c.Book(newBookRequest())
+	assert.Error(t, err)
+	assert.True(t, canceled)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "O1", result.Order.ID)
+	}
+}
+
+func TestBookValidate(t *testing.T) {
+	req := newBookRequest()
+	req.Recipients[3] = order.DeliveryDetail{Name: "Nobody", Phone: "+85238485760"}
+	_, err := cli.Book(req)
+	assert.Error(t, err)
+
+	req = newBookRequest()
+	req.Sender = order.Contact{}
+	_, err = req.buildOrder(&quotation.QuotationDetail{ID: "Q1", Quotation: *req.Quotation}, []int{0, 1, 2})
+	assert.Error(t, err)
+}
+
+func TestBookNormalizesPhones(t *testing.T) {
+	placed := &order.Order{}
+	canceled := false
+	srv := newBookServer(t, enum.ORDER_STATUS_ASSIGN, placed, &canceled)
+	defer srv.Close()
+
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).SetEndpoint(srv.URL)
+
+	req := newBookRequest()
+	req.Sender.Phone = "3848 5765"
+	req.Recipients[2] = order.DeliveryDetail{Name: "Katrina", Phone: "(852) 6123-4567"}
+	_, err := c.Book(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "+85238485765", placed.Sender.Phone)
+	if assert.Len(t, placed.Recipients, 2) {
+		assert.Equal(t, "+85238485761", placed.Recipients[0].Phone)
+		assert.Equal(t, "+85261234567", placed.Recipients[1].Phone)
+	}
+
+	req = newBookRequest()
+	req.Sender.Phone = "1234"
+	_, err = c.Book(req)
+	assert.ErrorContains(t, err, "sender: invalid phone number")
+}
+
+func TestNormalizePhonesKeepsCallerData(t *testing.T) {
+	placed := &order.Order{}
+	canceled := false
+	srv := newBookServer(t, enum.ORDER_STATUS_ASSIGN, placed, &canceled)
+	defer srv.Close()
+
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).SetEndpoint(srv.URL)
+
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE}
+	q.AddStop(quotation.DeliveryStop{Address: "A0", Phone: "3848 5765"}).
+		AddStop(quotation.DeliveryStop{Address: "A1"})
+	assert.NoError(t, q.Err())
+	_, err := c.GetQuotations(q)
+	assert.NoError(t, err)
+	assert.Equal(t, "3848 5765", q.Stops[0].Phone)
+
+	o := &order.Order{
+		QuotationId: "Q1",
+		Sender: order.Contact{StopId: "S0", Name: "Michal", Phone: "3848 5765"},
+		Recipients: []order.DeliveryDetail{{StopId: "S1", Name: "Katrina", Phone: "6123-4567"}},
+	}
+	_, err = c.PlaceOrder(o)
+	assert.NoError(t, err)
+	assert.Equal(t, "+85238485765", placed.Sender.Phone)
+	assert.Equal(t, "3848 5765", o.Sender.Phone)
+	assert.Equal(t, "6123-4567", o.Recipients[0].Phone)
+
+	stops := []quotation.DeliveryStop{{Address: "A0", Phone: "3848 5765"}, {Address: "A1"}}
+	c.EditOrder("O1", stops)
+	assert.Equal(t, "3848 5765", stops[0].Phone)
+}
+
+func TestPlanStopOrder(t *testing.T) {
+	// 返回的站点中取货站点被移到第二位
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := struct{ Data quotation.Quotation `json:"data"` }{}
+		assert.NoError(t, json.Unmarshal(body, &req))
+		stops := req.Data.Stops
+		stops[0], stops[1] = stops[1], stops[0]
+		data, _ := json.Marshal(stops)
+		w.Write([]byte(`{"data":{"quotationId":"Q1","stops":` + string(data) + `,"priceBreakdown":{"total":"100","currency":"HKD"}}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).SetEndpoint(srv.URL)
+	req := PlanRequest{
+		Template: quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, IsRouteOptimized: true},
+		Pickup: quotation.DeliveryStop{Address: "A0", Coordinates: quotation.Coordinates{Lat: "22.1", Lng: "114.1"}},
+		Dropoffs: []quotation.DeliveryStop{
+			{Address: "A1", Coordinates: quotation.Coordinates{Lat: "22.2", Lng: "114.2"}},
+			{Address: "A2", Coordinates: quotation.Coordinates{Lat: "22.3", Lng: "114.3"}},
+		},
+	}
+	_, err := c.Plan(req)
+	assert.ErrorContains(t, err, "moved the pickup stop")
+
+	// 未开启路线优化时按提交顺序对应
+	req.Template.IsRouteOptimized = false
+	plan, err := c.Plan(req)
+	assert.NoError(t, err)
+	if assert.Len(t, plan.Quotes, 1) {
+		assert.Equal(t, []int{0, 1, 2}, plan.Quotes[0].StopOrder)
+	}
+	_, stop, ok := plan.Locate(plan.Quotes[0].Dropoffs[0])
+	assert.True(t, ok)
+	assert.Equal(t, 1, stop)
+}
@@ -0,0 +1,281 @@
+package lalamove
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/logger"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/util"
+)
+
+// 演练模式下模拟返回的报价单ID/订单ID
+const DRY_RUN_ID = "DRY_RUN"
+
+// ErrInvalidRequest	演练模式下请求未通过本地校验
+var ErrInvalidRequest = errors.New("dry run: invalid request")
+
+// DryRunRequest	演练模式下未发送的请求 (已签名)
+type DryRunRequest struct {
+	Method string `json:"method"`
+	URL string `json:"url"`
+	Header http.Header `json:"header"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// DryRun	演练模式开关; 开启后修改类请求 (下单、编辑、小费、取消、更换司机、设置webhook等) 只签名及校验, 不发送,
+// 并返回根据请求内容构造的模拟结果 (APIResult.DryRun 不为空). 演练模式下不记录订单存储
+func (cli *Client) DryRun(toggle bool) *Client {
+	cli.dryRun = toggle
+	return cli
+}
+// DryRunReads	演练模式下是否同时拦截查询请求 (GET 请求及报价)
+func (cli *Client) DryRunReads(toggle bool) *Client {
+	cli.dryRunReads = toggle
+	return cli
+}
+// OnDryRun	设置演练模式下每个未发送请求的回调
+func (cli *Client) OnDryRun(fn func(req DryRunRequest)) *Client {
+	cli.onDryRun = fn
+	return cli
+}
+// 是否演练模式
+func (cli Client) IsDryRun() bool {
+	return cli.dryRun
+}
+
+// IsDryRunResult	调用结果是否来自演练模式 (模拟结果或本地校验失败); 指标统计等拦截器据此跳过
+func IsDryRunResult(result *APIResult, err error) bool {
+	return (result != nil && result.DryRun != nil) || errors.Is(err, ErrInvalidRequest)
+}
+
+// dryRunApplies	请求是否在演练模式下拦截
+func (cli Client) dryRunApplies(method, uri string) bool {
+	return cli.dryRun && (!isRead(method, uri) || cli.dryRunReads)
+}
+
+// isRead	是否为不修改数据的查询请求; 报价 (POST /v3/quotations) 不产生订单, 视为查询
+func isRead(method, uri string) bool {
+	return method == METHOD_GET || (method == METHOD_POST && Endpoint(uri) == "/"+Version+"/quotations")
+}
+
+// dryRunResult	校验请求并构造模拟结果; result.Request 已签名
+func (cli Client) dryRunResult(call *Call, result *APIResult) (*APIResult, error) {
+	if err := validateCall(call); err != nil {
+		result.logger.LogAttrs(context.Background(), slog.LevelWarn, "lalamove dry run rejected", append(result.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
+		return nil, err
+	}
+
+	req := DryRunRequest{
+		Method: call.Method,
+		URL: result.Request.URL.String(),
+		Header: result.Request.Header.Clone(),
+	}
+	if len(call.Payload) > 0 {
+		req.Body = json.RawMessage(call.Payload)
+	}
+	if cli.onDryRun != nil {
+		cli.onDryRun(req)
+	}
+
+	status, body := dryRunResponse(call)
+	result.Body = body
+	result.DryRun = &req
+	result.Response = &http.Response{
+		StatusCode: status,
+		Status: strconv.Itoa(status) + " " + http.StatusText(status),
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body: ioutil.NopCloser(bytes.NewReader(body)),
+		Request: result.Request,
+	}
+
+	result.logger.LogAttrs(context.Background(), slog.LevelInfo, "lalamove dry run", result.logAttrs()...)
+	if cli.debug {
+		result.printStackLog()
+	}
+	return result, nil
+}
+
+// dryRunResponse	根据请求内容构造模拟响应; 返回内容为请求 data 字段加上路径中的ID
+func dryRunResponse(call *Call) (int, []byte) {
+	endpoint := Endpoint(call.URI)
+	if call.Method == METHOD_DELETE {
+		return http.StatusNoContent, nil
+	}
+	if endpoint == "/"+Version+"/cities" {
+		return http.StatusOK, []byte(`{"data":[]}`)
+	}
+
+	payload := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	json.Unmarshal(call.Payload, &payload)
+	data := payload.Data
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	for resource, key := range map[string]string{"quotations": "quotationId", "orders": "orderId", "drivers": "driverId"} {
+		if id := PathID(call.URI, resource); id != "" {
+			data[key] = id
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case call.Method == METHOD_POST && endpoint == "/"+Version+"/quotations":
+		status = http.StatusCreated
+		data["quotationId"] = DRY_RUN_ID
+		// 为站点分配ID, 便于后续构建订单
+		if stops, ok := data["stops"].([]interface{}); ok {
+			for i, stop := range stops {
+				if s, ok := stop.(map[string]interface{}); ok {
+					s["stopId"] = DRY_RUN_ID + "_" + strconv.Itoa(i)
+				}
+			}
+		}
+	case call.Method == METHOD_POST && endpoint == "/"+Version+"/orders":
+		status = http.StatusCreated
+		data["orderId"] = DRY_RUN_ID
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"data": data})
+	return status, body
+}
+
+// invalid	校验失败
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// validateCall	本地校验请求路径及内容
+func validateCall(call *Call) error {
+	path, _, _ := strings.Cut(call.URI, "?")
+	if strings.Contains(path, "//") || strings.HasSuffix(path, "/") {
+		return invalid("%s %s: missing path id", call.Method, call.URI)
+	}
+
+	endpoint := Endpoint(call.URI)
+	data := json.RawMessage{}
+	if len(call.Payload) > 0 {
+		payload := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+		if err := json.Unmarshal(call.Payload, &payload); err != nil {
+			return invalid("%s %s: %s", call.Method, endpoint, err.Error())
+		}
+		data = payload.Data
+	}
+
+	switch call.Method + " " + endpoint {
+	case METHOD_POST + " /" + Version + "/quotations":
+		q := quotation.Quotation{}
+		if err := json.Unmarshal(data, &q); err != nil {
+			return invalid("quotation: %s", err.Error())
+		}
+		if q.ServiceType == "" {
+			return invalid("quotation: serviceType is required")
+		}
+		return validateStops(q.Stops)
+
+	case METHOD_POST + " /" + Version + "/orders":
+		o := order.Order{}
+		if err := json.Unmarshal(data, &o); err != nil {
+			return invalid("order: %s", err.Error())
+		}
+		if o.QuotationId == "" {
+			return invalid("order: quotationId is required")
+		}
+		if err := validateContact("sender", o.Sender.StopId, o.Sender.Name, o.Sender.Phone); err != nil {
+			return err
+		}
+		if len(o.Recipients) == 0 {
+			return invalid("order: at least one recipient is required")
+		}
+		for i, r := range o.Recipients {
+			if err := validateContact(fmt.Sprintf("recipient %d", i+1), r.StopId, r.Name, r.Phone); err != nil {
+				return err
+			}
+		}
+
+	case METHOD_PATCH + " /" + Version + "/orders/{orderId}":
+		edit := struct {
+			Stops []quotation.DeliveryStop `json:"stops"`
+		}{}
+		if err := json.Unmarshal(data, &edit); err != nil {
+			return invalid("edit order: %s", err.Error())
+		}
+		return validateStops(edit.Stops)
+
+	case METHOD_POST + " /" + Version + "/orders/{orderId}/priority-fee":
+		fee := struct {
+			PriorityFee string `json:"priorityFee"`
+		}{}
+		json.Unmarshal(data, &fee)
+		if v, err := strconv.ParseFloat(fee.PriorityFee, 64); err != nil || v <= 0 {
+			return invalid("priority fee %q must be a positive number", fee.PriorityFee)
+		}
+
+	case METHOD_DELETE + " /" + Version + "/orders/{orderId}/drivers/{driverId}":
+		change := struct {
+			Reason string `json:"reason"`
+		}{}
+		json.Unmarshal(data, &change)
+		switch change.Reason {
+		case enum.RESON_LATE, enum.RESON_CHANGED, enum.RESON_UNRESPONSIVE, enum.RESON_RUDE:
+		default:
+			return invalid("change driver: unknown reason %q", change.Reason)
+		}
+
+	case METHOD_PATCH + " /" + Version + "/webhook":
+		hook := struct {
+			URL string `json:"url"`
+		}{}
+		json.Unmarshal(data, &hook)
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalid("webhook url %q must be an absolute http(s) url", hook.URL)
+		}
+	}
+	return nil
+}
+
+// validateStops	校验站点数量、地址及坐标
+func validateStops(stops []quotation.DeliveryStop) error {
+	if len(stops) < enum.QUOT_STOPS_MIN || len(stops) > enum.QUOT_STOPS_MAX {
+		return invalid("stops must be between %d and %d, got %d", enum.QUOT_STOPS_MIN, enum.QUOT_STOPS_MAX, len(stops))
+	}
+	for i, stop := range stops {
+		if stop.Address == "" {
+			return invalid("stop %d: address is required", i)
+		}
+		if _, _, err := stop.Coordinates.Float(); err != nil {
+			return invalid("stop %d: invalid coordinates (%s, %s)", i, stop.Coordinates.Lat, stop.Coordinates.Lng)
+		}
+		if stop.Phone != "" && !util.CheckPhone(stop.Phone) {
+			return invalid("stop %d: invalid phone %q", i, stop.Phone)
+		}
+	}
+	return nil
+}
+
+// validateContact	校验联系人
+func validateContact(who, stopID, name, phone string) error {
+	if stopID == "" || name == "" || phone == "" {
+		return invalid("%s: stopId, name and phone are required", who)
+	}
+	if !util.CheckPhone(phone) {
+		return invalid("%s: invalid phone %q", who, phone)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package lalamove
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/store"
+	"github.com/eddielau42/lalamove-go-api/util"
+)
+
+func TestDryRun(t *testing.T) {
+	sent := make([]string, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"data":{"orderId":"O1","status":"ON_GOING"}}`))
+	}))
+	defer srv.Close()
+
+	requests := make([]DryRunRequest, 0)
+	mem := store.NewMemoryStore()
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint(srv.URL).
+		SetStore(mem).
+		DryRun(true).
+		OnDryRun(func(req DryRunRequest) { requests = append(requests, req) })
+	assert.True(t, c.IsDryRun())
+
+	// 查询请求默认照常发送
+	od, err := c.GetOrderDetail("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ON_GOING", od.Status)
+
+	// 报价不产生订单, 同样视为查询
+	_, err = c.GetQuotations(&quotation.Quotation{
+		ServiceType: enum.SERVICE_TYPE_MOTORCYCLE,
+		Stops: []quotation.DeliveryStop{
+			{Address: "Innocentre", Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}},
+			{Address: "Cyberport", Coordinates: quotation.Coordinates{Lat: "22.2630", Lng: "114.1308"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	placed, err := c.PlaceOrder(&order.Order{
+		QuotationId: "Q1",
+		Sender: order.Contact{StopId: "S0", Name: "Michal", Phone: "+85238485765"},
+		Recipients: []order.DeliveryDetail{{StopId: "S1", Name: "Katrina", Phone: "+85238485760"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, DRY_RUN_ID, placed.ID)
+	assert.Equal(t, "Q1", placed.QuotationId)
+
+	edited, err := c.EditOrder("O1", []quotation.DeliveryStop{
+		{Address: "Innocentre", Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}},
+		{Address: "Cyberport", Coordinates: quotation.Coordinates{Lat: "22.2630", Lng: "114.1308"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "O1", edited.ID)
+
+	fee, err := c.AddPriorityFee("O1", "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", fee.PriorityFee)
+
+	ok, err := c.CancelOrder("O1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.ChangeDriver("O1", "D1", enum.RESON_LATE)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.SetWebhook("https://example.com/hook")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.Equal(t, []string{"GET /v3/orders/O1", "POST /v3/quotations"}, sent)
+	if assert.Len(t, requests, 6) {
+		assert.Equal(t, METHOD_POST, requests[0].Method)
+		assert.Equal(t, srv.URL+"/v3/orders", requests[0].URL)
+		assert.True(t, strings.HasPrefix(requests[0].Header.Get("Authorization"), "hmac "+apikey+":"))
+		assert.Equal(t, "HK", requests[0].Header.Get("Market"))
+		assert.True(t, json.Valid(requests[0].Body))
+	}
+
+	// 演练模式下不记录订单存储
+	_, err = mem.GetOrder(DRY_RUN_ID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = mem.GetOrder("O1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestDryRunValidation(t *testing.T) {
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		DryRunReads(true)
+
+	_, err := c.PlaceOrder(&order.Order{
+		QuotationId: "Q1",
+		Sender: order.Contact{StopId: "S0", Name: "", Phone: "38485765"},
+		Recipients: []order.DeliveryDetail{{StopId: "S1", Name: "Katrina", Phone: "+85238485760"}},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidRequest))
+	assert.Contains(t, err.Error(), "sender: stopId, name and phone are required")
+
+	// 本地格式的电话号码在下单前转换, 无效号码不发送
+	_, err = c.PlaceOrder(&order.Order{
+		QuotationId: "Q1",
+		Sender: order.Contact{StopId: "S0", Name: "Michal", Phone: "3848576"},
+		Recipients: []order.DeliveryDetail{{StopId: "S1", Name: "Katrina", Phone: "+85238485760"}},
+	})
+	assert.ErrorIs(t, err, util.ErrInvalidPhone)
+	assert.Contains(t, err.Error(), "sender")
+
+	_, err = c.EditOrder("O1", []quotation.DeliveryStop{{Address: "Innocentre"}})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	_, err = c.AddPriorityFee("O1", "-5")
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	// 链式添加站点时超出上限
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE}
+	for i := 0; i <= enum.QUOT_STOPS_MAX; i++ {
+		q.AddStop(quotation.DeliveryStop{Address: "A", Coordinates: quotation.Coordinates{Lat: "22.3", Lng: "114.1"}})
+	}
+	_, err = c.GetQuotations(q)
+	assert.ErrorIs(t, err, quotation.ErrTooManyStops)
+
+	_, err = c.ChangeDriver("O1", "D1", "TOO_SLOW")
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	_, err = c.CancelOrder("")
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	_, err = c.SetWebhook("your.webhook.link")
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	// 拦截查询请求
+	od, err := c.GetOrderDetail("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, "O1", od.ID)
+	cities, err := c.GetCityInfo()
+	assert.NoError(t, err)
+	assert.Empty(t, cities)
+}
+
+func TestDryRunBook(t *testing.T) {
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		DryRunReads(true)
+
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, Language: enum.LANG_EN_HK}
+	q.AddStop(quotation.DeliveryStop{Address: "Innocentre", Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}, Name: "Michal", Phone: "+85238485765"}).
+		AddStop(quotation.DeliveryStop{Address: "Cyberport", Coordinates: quotation.Coordinates{Lat: "22.2630", Lng: "114.1308"}, Name: "Katrina", Phone: "+85238485760"})
+	assert.NoError(t, q.Err())
+
+	result, err := c.Book(BookRequest{Quotation: q})
+	assert.NoError(t, err)
+	assert.Equal(t, DRY_RUN_ID, result.Quotation.ID)
+	assert.Equal(t, DRY_RUN_ID, result.Order.ID)
+}
+
+func TestQuotationScheduleWindow(t *testing.T) {
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		DryRunReads(true)
+
+	scheduler, err := c.Scheduler()
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Hong_Kong", scheduler.Location().String())
+
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, Language: enum.LANG_EN_HK}
+	q.AddStop(quotation.DeliveryStop{Address: "Innocentre", Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}}).
+		AddStop(quotation.DeliveryStop{Address: "Cyberport", Coordinates: quotation.Coordinates{Lat: "22.2630", Lng: "114.1308"}})
+	assert.NoError(t, q.Err())
+
+	// 已过去的时间不发送
+	q.SetScheduleAt(time.Now().Add(-time.Hour))
+	_, err = c.GetQuotations(q)
+	assert.ErrorIs(t, err, quotation.ErrScheduleWindow)
+
+	assert.NoError(t, scheduler.Apply(q, time.Now().Add(2*time.Hour)))
+	qd, err := c.GetQuotations(q)
+	assert.NoError(t, err)
+	scheduled, err := qd.ScheduledAt()
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), scheduled, time.Minute)
+}
+
+func TestQuotationScheduleUnknownMarket(t *testing.T) {
+	buf := &bytes.Buffer{}
+	c := NewClient(Config{
+		Apikey: apikey,
+		Secret: secret,
+		Country: "XX",
+		Logger: slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		DryRunReads(true)
+
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, Language: enum.LANG_EN_HK}
+	q.AddStop(quotation.DeliveryStop{Address: "Innocentre", Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}}).
+		AddStop(quotation.DeliveryStop{Address: "Cyberport", Coordinates: quotation.Coordinates{Lat: "22.2630", Lng: "114.1308"}})
+	assert.NoError(t, q.Err())
+	q.SetScheduleAt(time.Now().Add(2 * time.Hour))
+
+	// 未知市场时不校验预约时间, 记录调试日志
+	_, err := c.GetQuotations(q)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"msg":"schedule window not validated"`)
+	assert.Contains(t, buf.String(), `"market":"XX"`)
+}
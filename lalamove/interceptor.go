@@ -0,0 +1,153 @@
+package lalamove
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/logger"
+)
+
+// Call	一次API调用; 拦截器可修改其内容后再交给下一环节
+type Call struct {
+	Context context.Context
+	// 市场 (地区编码)
+	Market string
+	Method string
+	URI string
+	Payload []byte
+	// 追加的请求头; 不可包含保留请求头 (Authorization、Request-ID、Market、Content-Type、Accept), 否则请求返回 ErrReservedHeader
+	Header http.Header
+}
+
+// ErrReservedHeader	拦截器追加了由客户端设置的保留请求头
+var ErrReservedHeader = errors.New("reserved request header")
+
+// 由客户端设置 (参与签名或标识请求) 的请求头
+var reservedHeaders = []string{"Authorization", "Request-Id", "Market", "Content-Type", "Accept"}
+
+// checkHeader	校验拦截器追加的请求头不含保留请求头 (不区分大小写)
+func checkHeader(h http.Header) error {
+	for key := range h {
+		for _, reserved := range reservedHeaders {
+			if strings.EqualFold(key, reserved) {
+				return fmt.Errorf("%w: %s", ErrReservedHeader, key)
+			}
+		}
+	}
+	return nil
+}
+
+// RoundTrip	执行一次API调用
+type RoundTrip func(call *Call) (*APIResult, error)
+
+// Interceptor	请求拦截器; 包装下一环节, 可在调用前后观察或修改请求及结果, 也可不调用 next 直接返回结果
+type Interceptor func(next RoundTrip) RoundTrip
+
+// Use	注册拦截器; 先注册的位于外层
+func (cli *Client) Use(interceptors ...Interceptor) *Client {
+	cli.interceptors = append(cli.interceptors, interceptors...)
+	return cli
+}
+
+// chain	组合拦截器
+func (cli Client) chain() RoundTrip {
+	rt := RoundTrip(cli.roundTrip)
+	for i := len(cli.interceptors) - 1; i >= 0; i-- {
+		rt = cli.interceptors[i](rt)
+	}
+	return rt
+}
+
+// Endpoint	返回请求路径对应的接口模板 (如 /v3/orders/{orderId}); 用于链路追踪及指标统计
+func Endpoint(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		switch parts[i-1] {
+		case "quotations":
+			parts[i] = "{quotationId}"
+		case "orders":
+			parts[i] = "{orderId}"
+		case "drivers":
+			parts[i] = "{driverId}"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// PathID	返回请求路径中资源名 (如 orders、quotations、drivers) 之后的ID; 不存在时返回空
+func PathID(uri, resource string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		if parts[i-1] == resource {
+			return parts[i]
+		}
+	}
+	return ""
+}
+
+// NewAPIResult	构造API返回结果; 用于拦截器直接返回结果 (如测试替身)
+func NewAPIResult(call *Call, statusCode int, body []byte) *APIResult {
+	req, _ := http.NewRequestWithContext(call.Context, call.Method, call.URI, bytes.NewReader(call.Payload))
+	return &APIResult{
+		Request: req,
+		Payload: call.Payload,
+		Response: &http.Response{
+			StatusCode: statusCode,
+			Status: http.StatusText(statusCode),
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body: ioutil.NopCloser(bytes.NewReader(body)),
+			Request: req,
+		},
+		Body: body,
+	}
+}
+
+// LoggingInterceptor	记录每次调用的方法、路径、状态码及耗时 (不包含请求/响应内容)
+func LoggingInterceptor(l *slog.Logger) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) (*APIResult, error) {
+			start := time.Now()
+			result, err := next(call)
+
+			attrs := []slog.Attr{
+				slog.String(logger.KEY_METHOD, call.Method),
+				slog.String(logger.KEY_PATH, call.URI),
+				slog.Duration(logger.KEY_LATENCY, time.Since(start)),
+			}
+			if result != nil {
+				attrs = append(attrs, slog.String(logger.KEY_REQUEST_ID, result.ReqID))
+				if result.Response != nil {
+					attrs = append(attrs, slog.Int(logger.KEY_STATUS, result.Response.StatusCode))
+				}
+			}
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.Any(logger.KEY_ERROR, err))
+			}
+			l.LogAttrs(call.Context, level, "lalamove call", attrs...)
+			return result, err
+		}
+	}
+}
+
+// TimingInterceptor	每次调用结束后回调调用耗时
+func TimingInterceptor(observe func(call *Call, elapsed time.Duration, result *APIResult, err error)) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(call *Call) (*APIResult, error) {
+			start := time.Now()
+			result, err := next(call)
+			observe(call, time.Since(start), result, err)
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package lalamove
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+func TestInterceptorChain(t *testing.T) {
+	var traceHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get("X-Trace-Id")
+		w.Write([]byte(`{"data":{"orderId":"O1","status":"ON_GOING"}}`))
+	}))
+	defer srv.Close()
+
+	order := make([]string, 0)
+	trace := func(name string) Interceptor {
+		return func(next RoundTrip) RoundTrip {
+			return func(call *Call) (*APIResult, error) {
+				order = append(order, name+":before")
+				call.Header.Set("X-Trace-Id", "trace-1")
+				result, err := next(call)
+				order = append(order, name+":after")
+				return result, err
+			}
+		}
+	}
+
+	var (
+		timedURI string
+		timedStatus int
+	)
+	buf := &bytes.Buffer{}
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint(srv.URL).
+		Use(trace("outer"), trace("inner")).
+		Use(LoggingInterceptor(slog.New(slog.NewTextHandler(buf, nil)))).
+		Use(TimingInterceptor(func(call *Call, elapsed time.Duration, result *APIResult, err error) {
+			timedURI = call.URI
+			timedStatus = result.Response.StatusCode
+		}))
+
+	od, err := c.GetOrderDetail("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, "O1", od.ID)
+
+	assert.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, order)
+	assert.Equal(t, "trace-1", traceHeader)
+	assert.Equal(t, "/v3/orders/O1", timedURI)
+	assert.Equal(t, http.StatusOK, timedStatus)
+	assert.True(t, strings.Contains(buf.String(), "path=/v3/orders/O1"))
+	assert.True(t, strings.Contains(buf.String(), "status=200"))
+}
+
+func TestInterceptorFake(t *testing.T) {
+	// 不调用 next, 直接返回伪造结果
+	fake := func(next RoundTrip) RoundTrip {
+		return func(call *Call) (*APIResult, error) {
+			if call.Method == METHOD_DELETE {
+				return NewAPIResult(call, http.StatusNoContent, nil), nil
+			}
+			return NewAPIResult(call, http.StatusUnprocessableEntity, []byte(`{"errors":[{"id":"ERR_INVALID_FIELD","message":"invalid"}]}`)), nil
+		}
+	}
+	c := NewClient(Config{Apikey: apikey, Secret: secret}).SetEndpoint("http://127.0.0.1:0").Use(fake)
+
+	ok, err := c.CancelOrder("O1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = c.AddPriorityFee("O1", "10")
+	assert.EqualError(t, err, "[ERR_INVALID_FIELD] invalid")
+}
+
+func TestInterceptorReservedHeader(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte(`{"data":{"orderId":"O1","status":"ON_GOING"}}`))
+	}))
+	defer srv.Close()
+
+	for _, key := range []string{"Authorization", "request-id", "MARKET", "Content-type", "Accept"} {
+		c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+			SetEndpoint(srv.URL).
+			Use(func(next RoundTrip) RoundTrip {
+				return func(call *Call) (*APIResult, error) {
+					call.Header.Set(key, "override")
+					return next(call)
+				}
+			})
+
+		_, err := c.GetOrderDetail("O1")
+		assert.ErrorIs(t, err, ErrReservedHeader, key)
+	}
+	assert.False(t, called)
+}
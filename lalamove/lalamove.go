@@ -2,11 +2,13 @@ package lalamove
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,6 +19,7 @@ import (
 	"github.com/eddielau42/lalamove-go-api/model/driver"
 	"github.com/eddielau42/lalamove-go-api/model/order"
 	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/store"
 	"github.com/eddielau42/lalamove-go-api/util"
 )
 
@@ -36,27 +39,85 @@ type Client struct {
 	sandboxMode bool
 
 	debug bool
+
+	// 演练模式; 修改类请求只签名及校验, 不发送
+	dryRun bool
+	// 演练模式下同时拦截查询请求
+	dryRunReads bool
+	// 演练模式下未发送请求的回调
+	onDryRun func(req DryRunRequest)
+
+	// 自定义请求地址 (为空时根据沙箱模式选择)
+	endpoint string
+	// 自定义HTTP客户端
+	httpClient *http.Client
+
+	// 订单存储; 设置后自动记录报价、下单、编辑、小费、取消及状态快照
+	store store.OrderStore
+
+	// 结构化日志; 默认不输出
+	logger *slog.Logger
+	// 未脱敏的原始日志
+	rawLogger *slog.Logger
+	// 日志脱敏器
+	redactor *logger.Redactor
+	// Logfile 打开的日志文件; Close 时关闭
+	logCloser io.Closer
+
+	// 请求拦截器
+	interceptors []Interceptor
+
+	// 请求上下文; 默认 context.Background()
+	ctx context.Context
 }
 
 type Config struct {
 	Apikey string
 	Secret string
 	Country string
+
+	// 结构化日志; 为空时使用 Logfile
+	Logger *slog.Logger
+	// 日志文件; Logger 与 Logfile 均为空时不输出日志
 	Logfile string
+	// 日志文件切割配置; 未设置 MaxSize 及 Interval 时每日零点切割
+	LogRotate logger.RotateOptions
+	// 日志脱敏器; 为空时使用 logger.DefaultRedactor()
+	Redactor *logger.Redactor
 }
 
 // 创建客户端实例
 func NewClient(conf Config) *Client {
-	if conf.Logfile == "" {
-		conf.Logfile = "../lalamove.log"
-	}
-	logger.SetFile(conf.Logfile)
-
-	return &Client{
+	cli := &Client{
 		apiKey: conf.Apikey,
 		apiSecret: conf.Secret,
 		country: conf.Country,
+		redactor: conf.Redactor,
 	}
+
+	l := conf.Logger
+	if l == nil && conf.Logfile != "" {
+		opts := conf.LogRotate
+		if opts.MaxSize == 0 && opts.Interval == 0 {
+			opts.Interval = 24 * time.Hour
+		}
+		fileLogger, closer, err := logger.NewRotateFile(conf.Logfile, slog.LevelDebug, opts)
+		if err != nil {
+			slog.Default().Error("lalamove: open log file failed, logging disabled", "file", conf.Logfile, "error", err)
+		} else {
+			l, cli.logCloser = fileLogger, closer
+		}
+	}
+	return cli.SetLogger(l)
+}
+// Close	关闭 Config.Logfile 打开的日志文件
+func (cli *Client) Close() error {
+	if cli.logCloser == nil {
+		return nil
+	}
+	err := cli.logCloser.Close()
+	cli.logCloser = nil
+	return err
 }
 // 设置沙箱环境
 func (cli *Client) Sandbox() *Client {
@@ -73,6 +134,85 @@ func (cli *Client) Debug(toggle bool) *Client {
 	return cli
 }
 
+// WithContext	返回使用指定上下文发起请求的客户端副本; 用于超时控制及链路追踪
+func (cli *Client) WithContext(ctx context.Context) *Client {
+	c := *cli
+	c.ctx = ctx
+	return &c
+}
+// 返回请求上下文
+func (cli Client) Context() context.Context {
+	if cli.ctx == nil {
+		return context.Background()
+	}
+	return cli.ctx
+}
+
+// 设置请求地址; 用于代理或本地测试服务
+func (cli *Client) SetEndpoint(endpoint string) *Client {
+	cli.endpoint = strings.TrimRight(endpoint, "/")
+	return cli
+}
+// 设置HTTP客户端
+func (cli *Client) SetHTTPClient(httpCli *http.Client) *Client {
+	cli.httpClient = httpCli
+	return cli
+}
+
+// 设置结构化日志; 为 nil 时不输出日志. 输出内容经脱敏器处理
+func (cli *Client) SetLogger(l *slog.Logger) *Client {
+	cli.rawLogger = l
+	cli.applyRedactor()
+	return cli
+}
+// 设置日志脱敏器; 为 nil 时使用默认规则, 关闭脱敏可传入 logger.NewRedactor(nil, nil)
+func (cli *Client) SetRedactor(r *logger.Redactor) *Client {
+	cli.redactor = r
+	cli.applyRedactor()
+	return cli
+}
+// applyRedactor	使用脱敏器包装日志; apikey 及 secret 原文始终屏蔽
+func (cli *Client) applyRedactor() {
+	if cli.rawLogger == nil {
+		cli.logger = logger.Discard()
+		return
+	}
+	redactor := cli.redactor
+	if redactor == nil {
+		redactor = logger.DefaultRedactor()
+	}
+	redactor = redactor.WithSecrets(cli.apiKey, cli.apiSecret)
+	cli.logger = slog.New(logger.NewRedactHandler(cli.rawLogger.Handler(), redactor))
+}
+// 返回结构化日志
+func (cli Client) GetLogger() *slog.Logger {
+	return cli.logger
+}
+
+// 设置订单存储
+func (cli *Client) SetStore(s store.OrderStore) *Client {
+	cli.store = s
+	return cli
+}
+// 返回订单存储
+func (cli Client) GetStore() store.OrderStore {
+	return cli.store
+}
+// record	记录存储事件; 存储失败不影响接口调用结果
+func (cli *Client) record(e store.Event) {
+	if cli.store == nil || cli.dryRun {
+		return
+	}
+	if err := cli.store.Record(e); err != nil {
+		cli.logger.Error("order store record failed",
+			slog.String("event", e.Type),
+			slog.String(logger.KEY_ORDER_ID, e.OrderID),
+			slog.String(logger.KEY_MARKET, cli.country),
+			slog.Any(logger.KEY_ERROR, err),
+		)
+	}
+}
+
 // 设置国家地区
 func (cli *Client)SetCountry(country string) *Client {
 	cli.country = country
@@ -84,21 +224,60 @@ func (cli Client) GetCountry() string {
 }
 
 
-// GetQuotations	获取报价单
+// Scheduler	返回当前市场的预约时间工具 (按市场当地时间设置及校验预约时间)
+func (cli Client) Scheduler() (*quotation.Scheduler, error) {
+	return quotation.NewScheduler(cli.country)
+}
+
+// validateSchedule	校验报价单的预约时间是否在当前市场的可预约范围内; 未知市场时不校验
+func (cli Client) validateSchedule(q *quotation.Quotation) error {
+	if q.ScheduleAt == "" {
+		return nil
+	}
+	at, err := q.ScheduledAt()
+	if err != nil {
+		return fmt.Errorf("invalid scheduleAt %q: %w", q.ScheduleAt, err)
+	}
+	scheduler, err := cli.Scheduler()
+	if err != nil {
+		cli.logger.Debug("schedule window not validated",
+			slog.String(logger.KEY_MARKET, strings.ToUpper(cli.country)),
+			slog.Any(logger.KEY_ERROR, err),
+		)
+		return nil
+	}
+	return scheduler.Validate(at)
+}
+
+// GetQuotations	获取报价单; 站点联系电话将按市场转换为 E.164 格式 (不修改 q), 预约时间需在可预约范围内
 func (cli *Client) GetQuotations(q *quotation.Quotation) (*quotation.QuotationDetail, error) {
 	// [POST] /v3/quotations
 	uri := "/" + Version + "/quotations"
 
+	if q != nil {
+		if err := q.Err(); err != nil {
+			return nil, fmt.Errorf("quotation: %w", err)
+		}
+		copied := *q
+		copied.Stops = append([]quotation.DeliveryStop(nil), q.Stops...)
+		q = &copied
+		if err := q.NormalizePhones(cli.country); err != nil {
+			return nil, fmt.Errorf("quotation: %w", err)
+		}
+		if err := cli.validateSchedule(q); err != nil {
+			return nil, fmt.Errorf("quotation: %w", err)
+		}
+	}
+
 	payload, err := json.Marshal(map[string]interface{}{"data": q})
 	if err != nil {
 		// 解析请求数据失败
-		log.Fatalln(err)
+		cli.logMarshalError(uri, err)
 		return nil, err
 	}
 	
 	result, err := cli.Request(METHOD_POST, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -109,6 +288,9 @@ func (cli *Client) GetQuotations(q *quotation.Quotation) (*quotation.QuotationDe
 	if err != nil {
 		return nil, err
 	}
+	if data.Data != nil {
+		cli.record(store.QuotationEvent(data.Data))
+	}
 	return data.Data, nil
 }
 
@@ -120,7 +302,6 @@ func (cli *Client) GetQuotationDetail(quotationID string) (*quotation.QuotationD
 	var payload []byte
 	result, err := cli.Request(METHOD_GET, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -134,15 +315,24 @@ func (cli *Client) GetQuotationDetail(quotationID string) (*quotation.QuotationD
 	return data.Data, nil
 } 
 
-// PlaceOrder	下单
+// PlaceOrder	下单; 发件人及收件人电话将按市场转换为 E.164 格式 (不修改 o)
 func (cli *Client) PlaceOrder(o *order.Order) (*order.OrderDetail, error) {
 	// [POST] /v3/orders
 	uri := "/" + Version + "/orders"
 
+	if o != nil {
+		copied := *o
+		copied.Recipients = append([]order.DeliveryDetail(nil), o.Recipients...)
+		o = &copied
+		if err := o.NormalizePhones(cli.country); err != nil {
+			return nil, fmt.Errorf("order: %w", err)
+		}
+	}
+
 	payload, err := json.Marshal(map[string]interface{}{"data": o})
 	if err != nil {
 		// 解析请求数据失败
-		log.Fatalln(err)
+		cli.logMarshalError(uri, err)
 		return nil, err
 	}
 
@@ -150,7 +340,6 @@ func (cli *Client) PlaceOrder(o *order.Order) (*order.OrderDetail, error) {
 
 	result, err := cli.Request(METHOD_POST, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -161,6 +350,9 @@ func (cli *Client) PlaceOrder(o *order.Order) (*order.OrderDetail, error) {
 	if err != nil {
 		return nil, err
 	}
+	if data.Data != nil {
+		cli.record(store.OrderEvent(store.EVENT_ORDER_PLACED, data.Data))
+	}
 	return data.Data, nil
 }
 
@@ -172,7 +364,6 @@ func (cli *Client) GetOrderDetail(orderID string) (*order.OrderDetail, error) {
 	var payload []byte
 	result, err := cli.Request(METHOD_GET, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -183,6 +374,9 @@ func (cli *Client) GetOrderDetail(orderID string) (*order.OrderDetail, error) {
 	if err != nil {
 		return nil, err
 	}
+	if data.Data != nil {
+		cli.record(store.OrderEvent(store.EVENT_STATUS_SNAPSHOT, data.Data))
+	}
 	return data.Data, nil
 } 
 
@@ -194,7 +388,6 @@ func (cli *Client) GetDriverDetail(orderID, driverID string) (*driver.DriverDeta
 	var payload []byte
 	result, err := cli.Request(METHOD_GET, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -220,13 +413,12 @@ func (cli *Client) AddPriorityFee(orderID, fee string) (*order.OrderDetail, erro
 	})
 	if err != nil {
 		// 解析请求数据失败
-		log.Fatalln(err)
+		cli.logMarshalError(uri, err)
 		return nil, err
 	}
 
 	result, err := cli.Request(METHOD_POST, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -237,14 +429,24 @@ func (cli *Client) AddPriorityFee(orderID, fee string) (*order.OrderDetail, erro
 	if err != nil {
 		return nil, err
 	}
+	if data.Data != nil {
+		e := store.OrderEvent(store.EVENT_PRIORITY_FEE, data.Data)
+		e.Data = map[string]string{"priorityFee": fee}
+		cli.record(e)
+	}
 	return data.Data, nil
 }
 
-// EditOrder	编辑修改订单
+// EditOrder	编辑修改订单; 站点联系电话将按市场转换为 E.164 格式 (不修改 stops)
 func (cli *Client) EditOrder(orderID string, stops []quotation.DeliveryStop) (*order.OrderDetail, error) {
 	// [PATCH] /v3/orders/{orderId}
 	uri := "/" + Version + "/orders/" + orderID
 
+	stops = append([]quotation.DeliveryStop(nil), stops...)
+	if err := quotation.NormalizeStopPhones(stops, cli.country); err != nil {
+		return nil, fmt.Errorf("edit order: %w", err)
+	}
+
 	payload, err := json.Marshal(map[string]interface{}{
 		"data": map[string]interface{}{
 			"stops": stops,
@@ -252,13 +454,12 @@ func (cli *Client) EditOrder(orderID string, stops []quotation.DeliveryStop) (*o
 	})
 	if err != nil {
 		// 解析请求数据失败
-		log.Fatalln(err)
+		cli.logMarshalError(uri, err)
 		return nil, err
 	}
 
 	result, err := cli.Request(METHOD_PATCH, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -269,6 +470,9 @@ func (cli *Client) EditOrder(orderID string, stops []quotation.DeliveryStop) (*o
 	if err != nil {
 		return nil, err
 	}
+	if data.Data != nil {
+		cli.record(store.OrderEvent(store.EVENT_ORDER_EDITED, data.Data))
+	}
 	return data.Data, nil
 }
 
@@ -280,11 +484,11 @@ func (cli *Client) CancelOrder(orderID string) (bool, error) {
 	var payload []byte
 	result, err := cli.Request(METHOD_DELETE, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return false, err
 	}
 	
 	if result.Response.StatusCode == http.StatusNoContent {
+		cli.record(store.CancelEvent(orderID))
 		return true, nil
 	}
 
@@ -308,7 +512,6 @@ func (cli *Client) ChangeDriver(orderID, driverID, reason string) (bool, error)
 
 	result, err := cli.Request(METHOD_DELETE, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return false, err
 	}
 
@@ -331,7 +534,6 @@ func (cli *Client) GetCityInfo() ([]city.City, error) {
 	var payload []byte
 	result, err := cli.Request(METHOD_GET, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -346,6 +548,36 @@ func (cli *Client) GetCityInfo() ([]city.City, error) {
 	return data.Data, nil
 }
 
+// ErrWebhookUnsupported	当前环境不支持查询webhook地址
+var ErrWebhookUnsupported = errors.New("lalamove: webhook lookup is not supported")
+
+// GetWebhook	查询当前设置的webhook地址; 接口不支持查询时返回 ErrWebhookUnsupported
+func (cli *Client) GetWebhook() (string, error) {
+	// [GET] /v3/webhook
+	uri := "/" + Version + "/webhook"
+
+	var payload []byte
+	result, err := cli.Request(METHOD_GET, uri, payload)
+	if err != nil {
+		return "", err
+	}
+	if result.Response.StatusCode == http.StatusNotFound || result.Response.StatusCode == http.StatusMethodNotAllowed {
+		return "", ErrWebhookUnsupported
+	}
+
+	data := &struct{
+		Data struct{
+			URL string `json:"url"`
+		} `json:"data"`
+	}{}
+	err = result.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	return data.Data.URL, nil
+}
+
+// SetWebhook	设置webhook地址
 func (cli *Client) SetWebhook(url string) (bool, error) {
 	// [PATCH] /v3/webhook
 	uri := "/" + Version + "/webhook"
@@ -357,7 +589,6 @@ func (cli *Client) SetWebhook(url string) (bool, error) {
 	})
 	result, err := cli.Request(METHOD_PATCH, uri, payload)
 	if err != nil {
-		log.Fatalln(err)
 		return false, err
 	}
 
@@ -373,6 +604,16 @@ func (cli *Client) SetWebhook(url string) (bool, error) {
 }
 
 
+// logMarshalError	记录请求数据序列化失败
+func (cli *Client) logMarshalError(uri string, err error) {
+	cli.logger.Error("marshal request payload failed",
+		slog.String(logger.KEY_PATH, uri),
+		slog.String(logger.KEY_MARKET, strings.ToUpper(cli.country)),
+		slog.Any(logger.KEY_ERROR, err),
+	)
+}
+
+
 // 请求方法
 const (
 	METHOD_GET    = "GET"
@@ -389,6 +630,13 @@ type APIResult struct {
 	Payload []byte
 	Response *http.Response
 	Body []byte
+	// 请求耗时
+	Latency time.Duration
+	// 演练模式下未发送的请求; 不为空时 Response 为模拟结果
+	DryRun *DryRunRequest
+
+	market string
+	logger *slog.Logger
 }
 // Parse	解析返回数据
 func (r APIResult) Parse(bindData interface{}) error {
@@ -399,7 +647,7 @@ func (r APIResult) Parse(bindData interface{}) error {
 	if respStatusCode >= http.StatusOK && respStatusCode < http.StatusMultipleChoices {
 		err = json.Unmarshal(r.Body, &bindData)
 		if err != nil {
-			fmt.Printf("----- 解析数据错误! error: %s\n", err.Error())
+			r.log().LogAttrs(context.Background(), slog.LevelError, "parse response failed", append(r.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
 			return err
 		}
 		return nil
@@ -414,7 +662,7 @@ func (r APIResult) Parse(bindData interface{}) error {
 		}{}
 		err = json.Unmarshal(r.Body, &errData)
 		if err != nil {
-			fmt.Printf("----- 解析数据错误! error: %s\n", err.Error())
+			r.log().LogAttrs(context.Background(), slog.LevelError, "parse response failed", append(r.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
 			return err
 		}
 		
@@ -434,7 +682,7 @@ func (r APIResult) Parse(bindData interface{}) error {
 		}{}
 		err = json.Unmarshal(r.Body, &errData)
 		if err != nil {
-			fmt.Printf("----- 解析数据错误! error: %s\n", err.Error())
+			r.log().LogAttrs(context.Background(), slog.LevelError, "parse response failed", append(r.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
 			return err
 		}
 
@@ -444,57 +692,115 @@ func (r APIResult) Parse(bindData interface{}) error {
 	return nil
 }
 
-// printStackLog	打印API调用信息
+// printStackLog	打印API调用信息 (包含请求头及请求/响应内容)
 func (r APIResult) printStackLog() {
-	
-	reqHeader, _ := json.Marshal(r.Request.Header)
-
-	logLevel := logger.ERROR_LEVEL
-	tips := "请求失败!"
+	level := slog.LevelError
+	msg := "lalamove request failed"
 	if r.Response.StatusCode >= http.StatusOK && r.Response.StatusCode < http.StatusMultipleChoices {
-		logLevel = logger.DEBUG_LEVEL
-		tips = "请求成功!"
-	}
-
-	logger.WriteLog(logLevel,
-		"----> " + tips + " 打印API调用信息:\n"+
-		"------------------------------\n"+
-		"Req-ID: %s\nReq-URL: %s\nReq-Method: %s\nReq-Header: %+v\nReq-Body: %+v\nResp-StatusCode: %d\nResp-Body: %s\n"+
-		"------------------------------\n",
-		r.ReqID,
-		r.Request.URL,
-		r.Request.Method,
-		string(reqHeader),
-		string(r.Payload),
-		r.Response.StatusCode,
-		string(r.Body),
+		level = slog.LevelDebug
+		msg = "lalamove request succeeded"
+	}
+
+	attrs := append(r.logAttrs(),
+		slog.String("url", r.Request.URL.String()),
+		slog.Any("request_header", r.Request.Header),
+		slog.String("request_body", string(r.Payload)),
+		slog.String("response_body", string(r.Body)),
 	)
+	r.log().LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// logAttrs	返回请求的结构化日志字段
+func (r APIResult) logAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String(logger.KEY_REQUEST_ID, r.ReqID),
+		slog.String(logger.KEY_MARKET, r.market),
+	}
+	if r.Request != nil {
+		attrs = append(attrs,
+			slog.String(logger.KEY_METHOD, r.Request.Method),
+			slog.String(logger.KEY_PATH, r.Request.URL.Path),
+		)
+		if orderID := PathID(r.Request.URL.Path, "orders"); orderID != "" {
+			attrs = append(attrs, slog.String(logger.KEY_ORDER_ID, orderID))
+		}
+	}
+	if r.Response != nil {
+		attrs = append(attrs, slog.Int(logger.KEY_STATUS, r.Response.StatusCode))
+	}
+	return append(attrs, slog.Duration(logger.KEY_LATENCY, r.Latency))
+}
+
+func (r APIResult) log() *slog.Logger {
+	if r.logger == nil {
+		return logger.Discard()
+	}
+	return r.logger
 }
 
 
+
 type APIError struct {
 	ID string `json:"id"`
 	Message string `json:"message"`
 	Detail string `json:"detail"`
 }
 
-// 发起请求
+// ErrorID	返回失败结果的错误ID (取最后一条); 成功或无法解析时返回空
+func (r APIResult) ErrorID() string {
+	if r.Response == nil || r.Response.StatusCode < http.StatusBadRequest {
+		return ""
+	}
+	errData := struct{
+		Errors []APIError `json:"errors"`
+	}{}
+	if err := json.Unmarshal(r.Body, &errData); err != nil || len(errData.Errors) == 0 {
+		return ""
+	}
+	return errData.Errors[len(errData.Errors)-1].ID
+}
+
+// 发起请求; 依次经过已注册的拦截器
 func (cli Client) Request(method, uri string, params []byte) (*APIResult, error) {
+	call := &Call{
+		Context: cli.Context(),
+		Market: strings.ToUpper(cli.country),
+		Method: method,
+		URI: uri,
+		Payload: params,
+		Header: http.Header{},
+	}
+	return cli.chain()(call)
+}
+
+// roundTrip	签名并发送请求
+func (cli Client) roundTrip(call *Call) (*APIResult, error) {
 	var (
 		err error
+		method = call.Method
+		uri = call.URI
+		params = call.Payload
 	)
-	result := &APIResult{}
+	result := &APIResult{
+		market: call.Market,
+		logger: cli.logger,
+	}
+	if result.logger == nil {
+		result.logger = logger.Discard()
+	}
 
 	url := baseURL
 	if cli.IsSandbox() { // 沙箱环境
 		url = sandboxURL
 	}
+	if cli.endpoint != "" { // 自定义请求地址
+		url = cli.endpoint
+	}
 	url = url + uri
 
 	result.Payload = params
-	result.Request, err = http.NewRequest(method, url, bytes.NewBuffer(result.Payload))
+	result.Request, err = http.NewRequestWithContext(call.Context, method, url, bytes.NewBuffer(result.Payload))
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
@@ -515,22 +821,52 @@ func (cli Client) Request(method, uri string, params []byte) (*APIResult, error)
 	result.Request.Header.Add("Content-type", "application/json")
 	result.Request.Header.Add("Accept", "application/json")
 	result.Request.Header.Add("Request-ID", result.ReqID)
-	result.Request.Header.Add("Market", strings.ToUpper(cli.country))
+	result.Request.Header.Add("Market", result.market)
 	result.Request.Header.Add("Authorization", fmt.Sprintf("hmac %s:%s:%s", cli.apiKey, ms, signature))	
+	// 拦截器追加的请求头
+	if err := checkHeader(call.Header); err != nil {
+		return nil, err
+	}
+	for key, values := range call.Header {
+		for _, v := range values {
+			result.Request.Header.Add(key, v)
+		}
+	}
+
+	// 演练模式
+	if cli.dryRunApplies(method, uri) {
+		return cli.dryRunResult(call, result)
+	}
 	
-	httpCli := &http.Client{
-		Timeout: 30 * time.Second,
+	httpCli := cli.httpClient
+	if httpCli == nil {
+		httpCli = &http.Client{
+			Timeout: 30 * time.Second,
+		}
 	}
+	start := time.Now()
 	result.Response, err = httpCli.Do(result.Request)
 	if err != nil {
 		// 请求错误
-		log.Fatalln(err)
+		result.Latency = time.Since(start)
+		result.logger.LogAttrs(context.Background(), slog.LevelError, "lalamove request error", append(result.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
 		return nil, err
 	}
 
 	// 将响应数据读取存放到 "result.Body" 中
 	result.Body, err = ioutil.ReadAll(result.Response.Body)
 	defer result.Response.Body.Close()
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.logger.LogAttrs(context.Background(), slog.LevelError, "read response failed", append(result.logAttrs(), slog.Any(logger.KEY_ERROR, err))...)
+		return nil, err
+	}
+
+	level := slog.LevelInfo
+	if result.Response.StatusCode >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+	result.logger.LogAttrs(context.Background(), level, "lalamove request", result.logAttrs()...)
 
 	// 调试模式下
 	if cli.debug {
@@ -1,10 +1,13 @@
 package lalamove
 
 import (
+	"net/http"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eddielau42/lalamove-go-api/cassette"
 	"github.com/eddielau42/lalamove-go-api/enum"
 	"github.com/eddielau42/lalamove-go-api/model/order"
 	"github.com/eddielau42/lalamove-go-api/model/quotation"
@@ -20,12 +23,27 @@ var (
 )
 
 func init() {
+	// 默认回放 testdata/lalamove.json (手工编写的合成数据, 非沙箱录制; 重新录制后将被真实响应覆盖);
+	// 设置 LALAMOVE_CASSETTE=record 及 LALAMOVE_SANDBOX_APIKEY/LALAMOVE_SANDBOX_SECRET 时访问沙箱并重新录制
+	mode := os.Getenv("LALAMOVE_CASSETTE")
+	key, sec := apikey, secret
+	if mode == "" {
+		mode = cassette.MODE_REPLAY
+	} else {
+		key, sec = os.Getenv("LALAMOVE_SANDBOX_APIKEY"), os.Getenv("LALAMOVE_SANDBOX_SECRET")
+	}
+	recorder, err := cassette.New("testdata/lalamove.json", mode, key, sec)
+	if err != nil {
+		panic(err)
+	}
+
 	cli = NewClient(Config{
-		Apikey: apikey,
-		Secret: secret,
+		Apikey: key,
+		Secret: sec,
 		Country: enum.AREA_CODE_HK,
 		// Logfile: "../lalamove.log",
 	})
+	cli.SetHTTPClient(&http.Client{Transport: recorder})
 	// Set sandbox mode
 	cli.Sandbox()
 	// Set debug mode
@@ -257,4 +275,12 @@ func TestSetWebhook(t *testing.T) {
 		t.Logf("\n----> SetWebhook_error: %s", err.Error())
 	}
 	assert.True(t, ok)
-}
\ No newline at end of file
+}
+
+func TestGetWebhook(t *testing.T) {
+	url, err := cli.GetWebhook()
+	if err != nil {
+		t.Logf("\n----> GetWebhook_error: %s", err.Error())
+	}
+	assert.Equal(t, "https://your.webhook.link", url)
+}
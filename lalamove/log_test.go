@@ -0,0 +1,130 @@
+package lalamove
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/logger"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+)
+
+func TestStructuredLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"id":"ERR_ORDER_NOT_FOUND","message":"order not found"}]}`))
+	}))
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	c := NewClient(Config{
+		Apikey: apikey,
+		Secret: secret,
+		Country: enum.AREA_CODE_HK,
+		Logger: slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}).SetEndpoint(srv.URL)
+
+	_, err := c.GetOrderDetail("O1")
+	assert.EqualError(t, err, "[ERR_ORDER_NOT_FOUND] order not found")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if assert.Len(t, lines, 2) {
+		entry := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(lines[0], &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.NotEmpty(t, entry["request_id"])
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/v3/orders/O1", entry["path"])
+		assert.Equal(t, float64(404), entry["status"])
+		assert.Equal(t, "HK", entry["market"])
+		assert.Equal(t, "O1", entry["order_id"])
+		assert.Contains(t, entry, "latency")
+
+		assert.NoError(t, json.Unmarshal(lines[1], &entry))
+		assert.Equal(t, "ERROR", entry["level"])
+	}
+}
+
+func TestDefaultLoggerDiscards(t *testing.T) {
+	c := NewClient(Config{Apikey: apikey, Secret: secret})
+	assert.NotNil(t, c.GetLogger())
+	assert.False(t, c.GetLogger().Enabled(context.Background(), slog.LevelError))
+
+	c.SetLogger(nil)
+	assert.NotNil(t, c.GetLogger())
+}
+
+func TestLogRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		// 原样返回请求内容
+		w.Write([]byte(`{"data":` + string(body) + `}`))
+	}))
+	defer srv.Close()
+
+	const (
+		rawKey = "pk_test_0123456789abcdef"
+		rawSecret = "sk_test_fedcba9876543210"
+	)
+	buf := &bytes.Buffer{}
+	c := NewClient(Config{
+		Apikey: rawKey,
+		Secret: rawSecret,
+		Country: enum.AREA_CODE_HK,
+		Logger: slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}).SetEndpoint(srv.URL).Debug(true)
+
+	_, err := c.PlaceOrder(&order.Order{
+		QuotationId: "Q1",
+		Sender: order.Contact{StopId: "S0", Name: "Michal", Phone: "+85238485765"},
+		Recipients: []order.DeliveryDetail{
+			{StopId: "S1", Name: "Katrina", Phone: "+85238485760", Remarks: "Flat 12A, Canton Rd"},
+		},
+	})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "request_header")
+	for _, secret := range []string{rawKey, "0123456789abcdef", rawSecret, "fedcba9876543210", "Michal", "Katrina", "38485765", "38485760", "Canton Rd"} {
+		assert.NotContains(t, out, secret)
+	}
+}
+
+func TestLogfileRotates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lalamove.log")
+	now := time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)
+	c := NewClient(Config{
+		Apikey: apikey,
+		Secret: secret,
+		Logfile: path,
+		LogRotate: logger.RotateOptions{Interval: 24 * time.Hour, Now: func() time.Time { return now }},
+	})
+	c.GetLogger().Info("before midnight")
+	now = now.Add(2 * time.Minute)
+	c.GetLogger().Info("after midnight")
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "lalamove.log*"))
+	assert.Len(t, files, 2)
+	data, _ := os.ReadFile(path)
+	assert.Contains(t, string(data), "after midnight")
+	assert.NotContains(t, string(data), "before midnight")
+
+	// 无法打开日志文件时不输出日志
+	c = NewClient(Config{Apikey: apikey, Secret: secret, Logfile: filepath.Join(dir, "missing", "lalamove.log")})
+	assert.False(t, c.GetLogger().Enabled(context.Background(), slog.LevelError))
+	assert.NoError(t, c.Close())
+}
@@ -0,0 +1,83 @@
+package lalamove
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// PlanRequest	拆分报价请求; 收货站点数量不限
+type PlanRequest struct {
+	// 报价单模板 (车型, 语言, 预约时间, 特别要求, 物品等); 模板中的站点将被忽略
+	Template quotation.Quotation
+	Pickup quotation.DeliveryStop
+	Dropoffs []quotation.DeliveryStop
+	Options quotation.SplitOptions
+}
+
+// PlanQuote	一批站点的报价结果
+type PlanQuote struct {
+	quotation.Batch
+	Detail *quotation.QuotationDetail
+	// 报价单返回的各站点对应的提交站点下标 (路线优化后顺序可能改变, 取货站点始终为 0)
+	StopOrder []int
+}
+
+// Plan	拆分报价结果
+type Plan struct {
+	Quotes []PlanQuote
+	// 各批报价总价之和
+	Total float64
+	Currency string
+}
+
+// Locate	原收货站点 (PlanRequest.Dropoffs 的下标) 所在的报价单及其在报价单返回站点中的下标
+func (p *Plan) Locate(dropoff int) (quote, stop int, ok bool) {
+	for q, pq := range p.Quotes {
+		for i, idx := range pq.StopOrder {
+			if i > 0 && idx > 0 && pq.Dropoffs[idx-1] == dropoff {
+				return q, i, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Plan	将收货站点按地理位置拆分为多张报价单 (每张最多 15 个收货站点) 并逐批报价, 合计总价.
+// 任一批报价失败时返回已完成的报价及错误
+func (cli *Client) Plan(req PlanRequest) (*Plan, error) {
+	batches, err := quotation.Split(req.Template, req.Pickup, req.Dropoffs, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for i, batch := range batches {
+		qd, err := cli.GetQuotations(batch.Quotation)
+		if err != nil {
+			return plan, fmt.Errorf("plan: batch %d: %w", i, err)
+		}
+		if qd == nil || qd.ID == "" {
+			return plan, fmt.Errorf("plan: batch %d: empty quotation returned", i)
+		}
+		quote := PlanQuote{Batch: batch, Detail: qd}
+		quote.StopOrder, err = stopOrder(batch.Quotation, qd)
+		if err != nil {
+			return plan, fmt.Errorf("plan: batch %d: %w", i, err)
+		}
+
+		total, err := strconv.ParseFloat(qd.PriceBreakdown.Total, 64)
+		if err != nil {
+			return plan, fmt.Errorf("plan: batch %d: invalid total %q: %w", i, qd.PriceBreakdown.Total, err)
+		}
+		if plan.Currency == "" {
+			plan.Currency = qd.PriceBreakdown.Currency
+		} else if qd.PriceBreakdown.Currency != plan.Currency {
+			return plan, fmt.Errorf("plan: batch %d: currency %s differs from %s", i, qd.PriceBreakdown.Currency, plan.Currency)
+		}
+		plan.Quotes = append(plan.Quotes, quote)
+		plan.Total += total
+	}
+	return plan, nil
+}
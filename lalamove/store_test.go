@@ -0,0 +1,53 @@
+package lalamove
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+func TestClientStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == METHOD_POST && r.URL.Path == "/v3/orders/O1/priority-fee":
+			w.Write([]byte(`{"data":{"orderId":"O1","status":"ASSIGNING_DRIVER","priorityFee":"10"}}`))
+		case r.Method == METHOD_GET && r.URL.Path == "/v3/orders/O1":
+			w.Write([]byte(`{"data":{"orderId":"O1","status":"ON_GOING","metadata":{"ref":"A-1"}}}`))
+		case r.Method == METHOD_DELETE && r.URL.Path == "/v3/orders/O1":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	s := store.NewMemoryStore()
+	c := NewClient(Config{Apikey: apikey, Secret: secret, Country: enum.AREA_CODE_HK}).
+		SetEndpoint(srv.URL).
+		SetStore(s)
+
+	_, err := c.AddPriorityFee("O1", "10")
+	assert.NoError(t, err)
+	_, err = c.GetOrderDetail("O1")
+	assert.NoError(t, err)
+	ok, err := c.CancelOrder("O1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	o, err := s.GetOrder("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, enum.ORDER_STATUS_CANCELED, o.Status)
+
+	events, _ := s.Events("O1")
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, store.EVENT_PRIORITY_FEE, events[0].Type)
+		assert.Equal(t, store.EVENT_STATUS_SNAPSHOT, events[1].Type)
+		assert.Equal(t, store.EVENT_ORDER_CANCELED, events[2].Type)
+	}
+
+	orders, _ := s.FindByMetadata("ref", "A-1")
+	assert.Len(t, orders, 1)
+}
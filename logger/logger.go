@@ -2,29 +2,19 @@ package logger
 
 import (
 	"log"
-	"os"
 	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
 var (
 	// 日志读写句柄
 	logWriter *log.Logger
-	// 日志输出路径
-	output *os.File
+	// 日志输出 (支持切割)
+	output *RotateWriter
 	// 当前日志等级
 	logLevel int
-	// 当前日志文件名
-	logFile string
-
-	// 记录当前日期
-	curDay int
-
-	// 日志文件锁
-	fileLock *sync.RWMutex
 )
 
 // 日志等级
@@ -40,27 +30,34 @@ const (
 	callerDepth = 4
 )
 
-func init() {
-	fileLock = &sync.RWMutex{}
-}
-
 // SetLevel	设置日志等级
 func SetLevel(level int) {
 	logLevel = level
 }
 
-// SetFile	设置日志文件
+// SetFile	设置日志文件; 每日零点切割
 func SetFile(file string) {
-	var err error
-	if output, err = os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664); err != nil {
+	if err := SetRotateFile(file, RotateOptions{Interval: 24 * time.Hour}); err != nil {
 		panic(err)
-	} else {
-		curDay = time.Now().YearDay()
-		logFile = file
-		logWriter = log.New(output, "", log.Ldate|log.Lmicroseconds)
 	}
 }
 
+// SetRotateFile	设置日志文件及切割配置
+func SetRotateFile(file string, opts RotateOptions) error {
+	w, err := NewRotateWriter(file, opts)
+	if err != nil {
+		return err
+	}
+
+	prev := output
+	output = w
+	logWriter = log.New(output, "", log.Ldate|log.Lmicroseconds)
+	if prev != nil {
+		prev.Close()
+	}
+	return nil
+}
+
 func Debug(format string, args ...any) {
 	if logLevel <= DEBUG_LEVEL {
 		WriteLog(DEBUG_LEVEL, format, args...)
@@ -82,10 +79,11 @@ func Error(format string, args ...any) {
 	}
 }
 
-// WriteLog	日志内容写入
+// WriteLog	日志内容写入; 未设置日志文件时不输出
 func WriteLog(level int, format string, args ...any) {
-	checkDayChange()
-
+	if logWriter == nil {
+		return
+	}
 	logWriter.Printf(getLogLevelTag(level) + " " + getPrefix() + format, args...)
 }
 
@@ -126,24 +124,3 @@ func getLogLevelTag(level int) string {
 	}
 	return "[-]"
 }
-
-// checkDayChange	检查日期变更
-func checkDayChange() {
-	fileLock.Lock()
-	defer fileLock.Unlock()
-
-	// 日期无变更
-	day := time.Now().YearDay()
-	if day == curDay {
-		return
-	}
-
-	// 关闭日志文件
-	output.Close()
-	// 修改日志文件名; 添加昨日日期作为日志文件后缀 (如: log -> log.20060102)
-	sufferfix := time.Now().Add(-24 * time.Hour).Format("20060102")
-	os.Rename(logFile, logFile + "." + sufferfix)
-
-	// 重新设置日志文件
-	SetFile(logFile)
-}
\ No newline at end of file
@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"log/slog"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -9,11 +12,29 @@ const (
 )
 
 func TestLogger(t *testing.T) {
-	SetFile(logfile)
+	SetFile(t.TempDir() + "/" + logfile)
 	SetLevel(INFO_LEVEL)
 
 	Info("---> logger testing >> this is info output...")
 	// Debug("---> logger testing >> this is debug output...")
 	// Warn("---> logger testing >> this is warn output...")
 	// Error("---> logger testing >> this is error output...")
-}
\ No newline at end of file
+}
+func TestSlog(t *testing.T) {
+	Discard().Info("---> logger testing >> discarded")
+
+	file := t.TempDir() + "/lalamove.json.log"
+	l, closer, err := NewFile(file, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("---> logger testing >> this is slog output...", KEY_ORDER_ID, "O1")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(file)
+	if !strings.Contains(string(content), `"order_id":"O1"`) {
+		t.Errorf("unexpected log content: %s", content)
+	}
+}
@@ -0,0 +1,269 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// 默认掩码
+const MASK = "***"
+
+// Rule	字段脱敏规则; Keys 为 json 字段名或请求头名称 (不区分大小写)
+type Rule struct {
+	Keys []string
+	Mask func(value string) string
+}
+
+// Pattern	文本脱敏规则; 匹配内容替换为 Replace (支持 $1 等分组引用)
+type Pattern struct {
+	Regexp *regexp.Regexp
+	Replace string
+}
+
+// Redactor	日志脱敏器
+type Redactor struct {
+	rules map[string]func(string) string
+	patterns []Pattern
+	secrets []string
+}
+
+// NewRedactor	创建脱敏器
+func NewRedactor(rules []Rule, patterns []Pattern) *Redactor {
+	r := &Redactor{rules: make(map[string]func(string) string)}
+	for _, rule := range rules {
+		r.AddRule(rule)
+	}
+	r.patterns = append(r.patterns, patterns...)
+	return r
+}
+
+// DefaultRedactor	默认脱敏器; 屏蔽 API key、签名、手机号、姓名及地址
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		[]Rule{
+			{Keys: []string{"authorization"}, Mask: MaskAuthorization},
+			{Keys: []string{"phone"}, Mask: MaskPhone},
+			{Keys: []string{"name", "address", "remarks"}, Mask: MaskAll},
+			{Keys: []string{"apikey", "secret", "signature"}, Mask: MaskAll},
+		},
+		[]Pattern{
+			{Regexp: regexp.MustCompile(`\b(pk|sk)_(test|prod)_[0-9A-Za-z*]+`), Replace: "${1}_${2}_" + MASK},
+			{Regexp: regexp.MustCompile(`hmac [^\s",]+`), Replace: "hmac " + MASK},
+			{Regexp: regexp.MustCompile(`\+\d{7,15}\b`), Replace: "+" + MASK},
+			// 本地格式号码 (util.NormalizePhone 接受的格式, 如 "6123 4567"、"0912-345-678");
+			// 只屏蔽紧跟在 phone/tel/mobile 等字样之后的号码, 避免误伤价格、ID及时间戳
+			{Regexp: regexp.MustCompile(`(?i)((?:phone\w*|\btel\b|\bmobile\w*)[^\d+\n]{0,20})\d[\d \-()]{5,18}\d`), Replace: "${1}" + MASK},
+		},
+	)
+}
+
+// AddRule	添加字段脱敏规则
+func (r *Redactor) AddRule(rule Rule) *Redactor {
+	mask := rule.Mask
+	if mask == nil {
+		mask = MaskAll
+	}
+	for _, key := range rule.Keys {
+		r.rules[strings.ToLower(key)] = mask
+	}
+	return r
+}
+
+// AddPattern	添加文本脱敏规则
+func (r *Redactor) AddPattern(p Pattern) *Redactor {
+	r.patterns = append(r.patterns, p)
+	return r
+}
+
+// WithSecrets	返回额外屏蔽指定字符串 (如 apikey/secret 原文) 的脱敏器副本
+func (r *Redactor) WithSecrets(secrets ...string) *Redactor {
+	copied := &Redactor{
+		rules: r.rules,
+		patterns: r.patterns,
+		secrets: append([]string{}, r.secrets...),
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			copied.secrets = append(copied.secrets, secret)
+		}
+	}
+	return copied
+}
+
+// String	文本脱敏; 内容为 json 时按字段规则脱敏
+func (r *Redactor) String(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if redacted, ok := r.json([]byte(trimmed)); ok {
+			s = string(redacted)
+		}
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, MASK)
+	}
+	for _, p := range r.patterns {
+		s = p.Regexp.ReplaceAllString(s, p.Replace)
+	}
+	return s
+}
+
+// JSON	json 内容脱敏; 无法解析时按文本脱敏
+func (r *Redactor) JSON(data []byte) []byte {
+	return []byte(r.String(string(data)))
+}
+
+// Header	请求头脱敏
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for key, values := range h {
+		mask := r.rules[strings.ToLower(key)]
+		for _, v := range values {
+			if mask != nil {
+				v = mask(v)
+			}
+			redacted[key] = append(redacted[key], r.String(v))
+		}
+	}
+	return redacted
+}
+
+// Value	按字段名脱敏
+func (r *Redactor) Value(key, value string) string {
+	if mask, ok := r.rules[strings.ToLower(key)]; ok {
+		return mask(value)
+	}
+	return r.String(value)
+}
+
+// json	解析并递归脱敏 json
+func (r *Redactor) json(data []byte) ([]byte, bool) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(r.walk("", v))
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func (r *Redactor) walk(key string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = r.walk(k, item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.walk(key, item)
+		}
+		return val
+	case string:
+		return r.Value(key, val)
+	}
+	return v
+}
+
+// MaskAll	完全屏蔽
+func MaskAll(string) string {
+	return MASK
+}
+
+// MaskPhone	屏蔽手机号, 仅保留末2位
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return MASK
+	}
+	return MASK + phone[len(phone)-2:]
+}
+
+// MaskAuthorization	屏蔽授权头 (hmac key:timestamp:signature)
+func MaskAuthorization(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + MASK
+	}
+	return MASK
+}
+
+// redactHandler	对日志字段脱敏的 slog.Handler
+type redactHandler struct {
+	next slog.Handler
+	redactor *Redactor
+}
+
+// NewRedactHandler	返回写入前对所有日志字段及消息脱敏的 slog.Handler
+func NewRedactHandler(next slog.Handler, redactor *Redactor) slog.Handler {
+	if redactor == nil {
+		redactor = DefaultRedactor()
+	}
+	return &redactHandler{next: next, redactor: redactor}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.attr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// attr	字段脱敏
+func (h *redactHandler) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactor.Value(a.Key, v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = h.attr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch val := v.Any().(type) {
+		case http.Header:
+			return slog.Any(a.Key, h.redactor.Header(val))
+		case []byte:
+			return slog.String(a.Key, h.redactor.String(string(val)))
+		case json.RawMessage:
+			return slog.Any(a.Key, json.RawMessage(h.redactor.JSON(val)))
+		case error:
+			return slog.String(a.Key, h.redactor.String(val.Error()))
+		default:
+			// 其他类型序列化为 json 后脱敏
+			data, err := json.Marshal(val)
+			if err != nil {
+				return slog.String(a.Key, MASK)
+			}
+			return slog.Any(a.Key, json.RawMessage(h.redactor.JSON(data)))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
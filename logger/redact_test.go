@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactorJSON(t *testing.T) {
+	r := DefaultRedactor()
+	body := `{"data":{"sender":{"stopId":"1","name":"Michal","phone":"+85238485765"},` +
+		`"stops":[{"address":"Innocentre, 72 Tat Chee Ave","coordinates":{"lat":"22.33","lng":"114.17"}}],` +
+		`"quantity":12}}`
+
+	redacted := string(r.JSON([]byte(body)))
+	assert.NotContains(t, redacted, "Michal")
+	assert.NotContains(t, redacted, "85238485765")
+	assert.NotContains(t, redacted, "Innocentre")
+	assert.Contains(t, redacted, `"phone":"***65"`)
+	assert.Contains(t, redacted, `"stopId":"1"`)
+	assert.Contains(t, redacted, `"lat":"22.33"`)
+	assert.Contains(t, redacted, `"quantity":12`)
+}
+
+func TestRedactorText(t *testing.T) {
+	r := DefaultRedactor().WithSecrets("my-raw-secret")
+	text := "key pk_test_abcdef auth hmac pk_test_abcdef:1700000000000:0a1b2c phone +85238485765 my-raw-secret"
+
+	redacted := r.String(text)
+	assert.Equal(t, "key pk_test_*** auth hmac *** phone +*** ***", redacted)
+}
+
+func TestRedactorHeaderAndRules(t *testing.T) {
+	r := DefaultRedactor().AddRule(Rule{Keys: []string{"X-Partner-Token"}})
+	h := http.Header{}
+	h.Set("Authorization", "hmac pk_test_abcdef:1700000000000:0a1b2c")
+	h.Set("X-Partner-Token", "token-value")
+	h.Set("Market", "HK")
+
+	redacted := r.Header(h)
+	assert.Equal(t, "hmac ***", redacted.Get("Authorization"))
+	assert.Equal(t, MASK, redacted.Get("X-Partner-Token"))
+	assert.Equal(t, "HK", redacted.Get("Market"))
+	// 原请求头不变
+	assert.Equal(t, "token-value", h.Get("X-Partner-Token"))
+
+	custom := NewRedactor(nil, []Pattern{{Regexp: regexp.MustCompile(`ORDER-\d+`), Replace: "ORDER-" + MASK}})
+	assert.Equal(t, "ORDER-*** Michal", custom.String("ORDER-123 Michal"))
+}
+
+func TestRedactHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewRedactHandler(slog.NewJSONHandler(buf, nil), DefaultRedactor().WithSecrets("sk_raw"))
+	l := slog.New(h).With("apikey", "pk_test_abcdef")
+
+	header := http.Header{}
+	header.Set("Authorization", "hmac pk_test_abcdef:1700000000000:0a1b2c")
+	l.Info("request sk_raw",
+		"request_header", header,
+		"request_body", `{"name":"Katrina","phone":"+85238485760"}`,
+		slog.Group("recipient", "address", "Canton Rd", "phone", "+85238485760"),
+		"error", errors.New("invalid phone +85238485760"),
+		"payload", map[string]string{"name": "Katrina"},
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"sk_raw", "pk_test_abcdef", "0a1b2c", "Katrina", "85238485760", "Canton Rd"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "hmac ***")
+}
+
+func TestRedactorLocalPhone(t *testing.T) {
+	r := DefaultRedactor()
+	cases := map[string]string{
+		"phone 6123 4567": "phone ***",
+		"phone=61234567": "phone=***",
+		"Phone: 0912-345-678,": "Phone: ***,",
+		"tel (852) 6123-4567": "tel (***",
+		`sender: invalid phone number: "3848 5765"`: `sender: invalid phone number: "***"`,
+		"recipientPhone 9123 4567 ok": "recipientPhone *** ok",
+		// 非电话号码不屏蔽
+		"price 100 200": "price 100 200",
+		"order 107900701184 quotation 2723174418325999954": "order 107900701184 quotation 2723174418325999954",
+		"timestamp 1700000000 at 1700000000123": "timestamp 1700000000 at 1700000000123",
+		"lat 22.33361234 lng 114.1761581": "lat 22.33361234 lng 114.1761581",
+		"date 2024-10-19 qty 12": "date 2024-10-19 qty 12",
+		"call 6123 4567": "call 6123 4567",
+		"telemetry 12345678 hotel 1203 4567": "telemetry 12345678 hotel 1203 4567",
+	}
+	for text, want := range cases {
+		assert.Equal(t, want, r.String(text), text)
+	}
+}
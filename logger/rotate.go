@@ -0,0 +1,276 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 归档文件时间后缀格式 (如: lalamove.log.20060102-150405)
+const backupTimeFormat = "20060102-150405"
+
+// RotateOptions	日志切割配置
+type RotateOptions struct {
+	// 单个文件最大字节数; 为 0 时不按大小切割
+	MaxSize int64
+	// 按时间切割的周期 (如 24h 为每日零点切割); 为 0 时不按时间切割
+	Interval time.Duration
+	// 保留的归档文件数; 为 0 时不限制
+	MaxBackups int
+	// 归档文件保留时长; 为 0 时不限制
+	MaxAge time.Duration
+	// 是否 gzip 压缩归档文件
+	Compress bool
+	// 当前时间 (用于测试); 默认 time.Now
+	Now func() time.Time
+}
+
+// RotateWriter	支持按大小/时间切割的日志文件写入; 未触发切割时写入不加锁
+type RotateWriter struct {
+	path string
+	opts RotateOptions
+
+	file atomic.Pointer[os.File]
+	size atomic.Int64
+	// 下次按时间切割的时间点 (UnixNano); 为 0 时不按时间切割
+	next atomic.Int64
+
+	// 切割时加锁
+	mu sync.Mutex
+}
+
+// NewRotateWriter	创建日志切割写入
+func NewRotateWriter(path string, opts RotateOptions) (*RotateWriter, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	w := &RotateWriter{path: path, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write	写入日志; 超过大小或到达切割时间时先切割文件
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	if !w.shouldRotate(int64(len(p))) {
+		// 快速路径: 无锁写入
+		if file := w.file.Load(); file != nil {
+			n, err := file.Write(p)
+			if !errors.Is(err, os.ErrClosed) {
+				w.size.Add(int64(n))
+				return n, err
+			}
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 加锁后再次检查, 避免并发重复切割
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Load().Write(p)
+	w.size.Add(int64(n))
+	return n, err
+}
+
+// Rotate	立即切割日志文件
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Close	关闭日志文件
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if file := w.file.Swap(nil); file != nil {
+		return file.Close()
+	}
+	return nil
+}
+
+// shouldRotate	是否需要切割
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.opts.MaxSize > 0 && w.size.Load() > 0 && w.size.Load()+n > w.opts.MaxSize {
+		return true
+	}
+	if next := w.next.Load(); next > 0 && w.opts.Now().UnixNano() >= next {
+		return true
+	}
+	return false
+}
+
+// open	打开 (或创建) 日志文件 (调用方需持有锁或在初始化时调用)
+func (w *RotateWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.size.Store(info.Size())
+	if w.opts.Interval > 0 {
+		w.next.Store(nextBoundary(w.opts.Now(), w.opts.Interval).UnixNano())
+	}
+	w.file.Store(file)
+	return nil
+}
+
+// rotate	归档当前文件并重新打开 (调用方需持有锁)
+func (w *RotateWriter) rotate() error {
+	if file := w.file.Swap(nil); file != nil {
+		file.Close()
+	}
+
+	if info, err := os.Stat(w.path); err == nil && info.Size() > 0 {
+		backup := w.backupName(w.opts.Now())
+		if err := os.Rename(w.path, backup); err != nil {
+			return err
+		}
+		if w.opts.Compress {
+			if err := compressFile(backup); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+	return w.cleanup()
+}
+
+// backupName	返回不重复的归档文件名
+func (w *RotateWriter) backupName(t time.Time) string {
+	name := w.path + "." + t.In(time.Local).Format(backupTimeFormat)
+	candidate := name
+	for i := 1; exists(candidate) || exists(candidate+".gz"); i++ {
+		candidate = name + "." + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// backup	归档文件信息
+type backup struct {
+	path string
+	time time.Time
+	seq int
+}
+
+// cleanup	按保留数量及时长清理归档文件
+func (w *RotateWriter) cleanup() error {
+	if w.opts.MaxBackups <= 0 && w.opts.MaxAge <= 0 {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+	// 最新的在前
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].time.Equal(backups[j].time) {
+			return backups[i].seq > backups[j].seq
+		}
+		return backups[i].time.After(backups[j].time)
+	})
+
+	now := w.opts.Now()
+	for i, b := range backups {
+		expired := w.opts.MaxAge > 0 && now.Sub(b.time) > w.opts.MaxAge
+		excess := w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups
+		if expired || excess {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backups	列出归档文件
+func (w *RotateWriter) backups() ([]backup, error) {
+	dir := filepath.Dir(w.path)
+	prefix := filepath.Base(w.path) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]backup, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		stamp, seq, _ := strings.Cut(suffix, ".")
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(seq)
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t, seq: n})
+	}
+	return backups, nil
+}
+
+// nextBoundary	返回下一个切割时间点; 以当天零点 (本地时区) 为起点按周期划分
+func nextBoundary(t time.Time, interval time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	periods := t.Sub(midnight) / interval
+	return midnight.Add((periods + 1) * interval)
+}
+
+// compressFile	gzip 压缩文件并删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock	可控时钟
+type fakeClock struct {
+	mu sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func listBackups(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.Name() != "app.log" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2023, 6, 1, 10, 0, 0, 0, time.Local)}
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{MaxSize: 10, Now: clock.Now})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("12345678\n"))
+	w.Write([]byte("abcdefgh\n"))
+	w.Write([]byte("ABCDEFGH\n"))
+
+	assert.Equal(t, []string{"app.log.20230601-100000", "app.log.20230601-100000.1"}, listBackups(t, dir))
+	content, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "ABCDEFGH\n", string(content))
+	content, _ = os.ReadFile(filepath.Join(dir, "app.log.20230601-100000"))
+	assert.Equal(t, "12345678\n", string(content))
+}
+
+func TestRotateByTimeAcrossYear(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2023, 12, 31, 23, 59, 0, 0, time.Local)}
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{Interval: 24 * time.Hour, Now: clock.Now})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("last of 2023\n"))
+	clock.Add(30 * time.Second)
+	w.Write([]byte("still 2023\n"))
+	assert.Empty(t, listBackups(t, dir))
+
+	// 跨年切割 (YearDay 由 365 变为 1)
+	clock.Add(time.Minute)
+	w.Write([]byte("first of 2024\n"))
+	assert.Equal(t, []string{"app.log.20240101-000030"}, listBackups(t, dir))
+
+	content, _ := os.ReadFile(filepath.Join(dir, "app.log.20240101-000030"))
+	assert.Equal(t, "last of 2023\nstill 2023\n", string(content))
+	content, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "first of 2024\n", string(content))
+}
+
+func TestRotateRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2023, 6, 1, 0, 0, 0, 0, time.Local)}
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{
+		Interval: time.Hour,
+		MaxBackups: 3,
+		MaxAge: 90 * time.Minute,
+		Now: clock.Now,
+	})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 6; i++ {
+		w.Write([]byte("line\n"))
+		clock.Add(time.Hour)
+	}
+	w.Write([]byte("line\n"))
+
+	// 共切割6次; MaxBackups 保留3个, MaxAge 再淘汰超过90分钟的
+	assert.Equal(t, []string{"app.log.20230601-050000", "app.log.20230601-060000"}, listBackups(t, dir))
+}
+
+func TestRotateCompress(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2023, 6, 1, 10, 0, 0, 0, time.Local)}
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{Compress: true, Now: clock.Now})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	w.Write([]byte("compressed line\n"))
+	assert.NoError(t, w.Rotate())
+	assert.Equal(t, []string{"app.log.20230601-100000.gz"}, listBackups(t, dir))
+
+	file, err := os.Open(filepath.Join(dir, "app.log.20230601-100000.gz"))
+	assert.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(gz)
+	assert.Equal(t, "compressed line\n", string(content))
+}
+
+func TestRotateConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotateWriter(filepath.Join(dir, "app.log"), RotateOptions{MaxSize: 1024})
+	assert.NoError(t, err)
+
+	line := strings.Repeat("x", 99) + "\n"
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := w.Write([]byte(line))
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, w.Close())
+
+	// 所有内容均写入且未丢失
+	total := 0
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		content, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		total += len(content)
+	}
+	assert.Equal(t, 8*50*len(line), total)
+}
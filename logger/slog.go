@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// 结构化日志字段
+const (
+	KEY_REQUEST_ID = "request_id"
+	KEY_METHOD     = "method"
+	KEY_PATH       = "path"
+	KEY_STATUS     = "status"
+	KEY_LATENCY    = "latency"
+	KEY_MARKET     = "market"
+	KEY_ORDER_ID   = "order_id"
+	KEY_ERROR      = "error"
+)
+
+// discardHandler	丢弃所有日志
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Discard	返回不输出任何内容的结构化日志
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// NewFile	返回写入指定文件的结构化日志 (json格式); 不再使用时需关闭返回的 io.Closer
+func NewFile(file string, level slog.Level) (*slog.Logger, io.Closer, error) {
+	output, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: level})), output, nil
+}
+
+// NewRotateFile	返回写入指定文件并按配置切割的结构化日志 (json格式)
+func NewRotateFile(file string, level slog.Level, opts RotateOptions) (*slog.Logger, io.Closer, error) {
+	w, err := NewRotateWriter(file, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), w, nil
+}
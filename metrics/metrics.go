@@ -0,0 +1,166 @@
+// Package metrics	Prometheus 指标; 统计API调用、下单及 webhook 事件
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// 默认指标命名空间
+const NAMESPACE = "lalamove"
+
+// Collector	指标收集器 (prometheus.Collector);
+// 通过 Interceptor 接入 lalamove.Client, 通过 webhook.Receiver.Observer 接入 webhook 接收器
+type Collector struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	errors *prometheus.CounterVec
+	retries *prometheus.CounterVec
+	rateLimitWaits prometheus.Counter
+	rateLimitWaitSeconds prometheus.Counter
+	quotations *prometheus.CounterVec
+	ordersPlaced *prometheus.CounterVec
+	ordersCanceled *prometheus.CounterVec
+	webhookReceived *prometheus.CounterVec
+	webhookRejected *prometheus.CounterVec
+}
+
+// NewCollector	创建指标收集器; namespace 为空时使用 NAMESPACE
+func NewCollector(namespace string) *Collector {
+	if namespace == "" {
+		namespace = NAMESPACE
+	}
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, labels)
+	}
+
+	return &Collector{
+		requests: counter("requests_total", "Number of Lalamove API calls.", "market", "method", "endpoint", "status"),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name: "request_duration_seconds",
+			Help: "Duration of Lalamove API calls.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"market", "method", "endpoint"}),
+		errors: counter("errors_total", "Number of failed Lalamove API calls by error ID.", "market", "endpoint", "error_id"),
+		retries: counter("retries_total", "Number of retried Lalamove API calls and event deliveries.", "source", "target"),
+		rateLimitWaits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "rate_limit_waits_total",
+			Help: "Number of waits imposed by rate limiting.",
+		}),
+		rateLimitWaitSeconds: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: "rate_limit_wait_seconds_total",
+			Help: "Total time spent waiting for rate limiting.",
+		}),
+		quotations: counter("quotations_total", "Number of quotations created.", "market"),
+		ordersPlaced: counter("orders_placed_total", "Number of orders placed.", "market"),
+		ordersCanceled: counter("orders_canceled_total", "Number of orders canceled.", "market"),
+		webhookReceived: counter("webhook_events_received_total", "Number of verified webhook events.", "event_type"),
+		webhookRejected: counter("webhook_events_rejected_total", "Number of rejected webhook events.", "reason"),
+	}
+}
+
+// collectors	全部指标
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests, c.duration, c.errors, c.retries,
+		c.rateLimitWaits, c.rateLimitWaitSeconds,
+		c.quotations, c.ordersPlaced, c.ordersCanceled,
+		c.webhookReceived, c.webhookRejected,
+	}
+}
+
+// Describe	实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.collectors() {
+		m.Describe(ch)
+	}
+}
+
+// Collect	实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.collectors() {
+		m.Collect(ch)
+	}
+}
+
+// Interceptor	返回统计每次API调用的拦截器
+func (c *Collector) Interceptor() lalamove.Interceptor {
+	return func(next lalamove.RoundTrip) lalamove.RoundTrip {
+		return func(call *lalamove.Call) (*lalamove.APIResult, error) {
+			start := time.Now()
+			result, err := next(call)
+			c.Observe(call, time.Since(start), result, err)
+			return result, err
+		}
+	}
+}
+
+// Observe	记录一次API调用; 可配合 lalamove.TimingInterceptor 使用. 演练模式的请求未发送, 不计入指标
+func (c *Collector) Observe(call *lalamove.Call, elapsed time.Duration, result *lalamove.APIResult, err error) {
+	if lalamove.IsDryRunResult(result, err) {
+		return
+	}
+	endpoint := lalamove.Endpoint(call.URI)
+	c.duration.WithLabelValues(call.Market, call.Method, endpoint).Observe(elapsed.Seconds())
+
+	if err != nil || result == nil || result.Response == nil {
+		c.requests.WithLabelValues(call.Market, call.Method, endpoint, "error").Inc()
+		c.errors.WithLabelValues(call.Market, endpoint, "transport").Inc()
+		return
+	}
+
+	status := result.Response.StatusCode
+	c.requests.WithLabelValues(call.Market, call.Method, endpoint, strconv.Itoa(status)).Inc()
+	if status >= http.StatusBadRequest {
+		errorID := result.ErrorID()
+		if errorID == "" {
+			errorID = strconv.Itoa(status)
+		}
+		c.errors.WithLabelValues(call.Market, endpoint, errorID).Inc()
+		return
+	}
+
+	switch {
+	case call.Method == lalamove.METHOD_POST && endpoint == "/"+lalamove.Version+"/quotations":
+		c.quotations.WithLabelValues(call.Market).Inc()
+	case call.Method == lalamove.METHOD_POST && endpoint == "/"+lalamove.Version+"/orders":
+		c.ordersPlaced.WithLabelValues(call.Market).Inc()
+	case call.Method == lalamove.METHOD_DELETE && endpoint == "/"+lalamove.Version+"/orders/{orderId}":
+		c.ordersCanceled.WithLabelValues(call.Market).Inc()
+	}
+}
+
+// Retry	记录一次API调用重试; market 为市场代码, endpoint 为请求路径
+func (c *Collector) Retry(market, endpoint string) {
+	c.retries.WithLabelValues(market, lalamove.Endpoint(endpoint)).Inc()
+}
+
+// ForwardRetry	记录一次事件投递失败 (之后按退避间隔重试); 可用作 forwarder.Forwarder.OnError
+func (c *Collector) ForwardRetry(e webhook.Event, attempt int, err error) {
+	c.retries.WithLabelValues("forwarder", e.EventType).Inc()
+}
+
+// RateLimitWait	记录一次限速等待; 可用作 batch.Runner.OnWait
+func (c *Collector) RateLimitWait(d time.Duration) {
+	c.rateLimitWaits.Inc()
+	c.rateLimitWaitSeconds.Add(d.Seconds())
+}
+
+// Received	实现 webhook.Observer
+func (c *Collector) Received(eventType string) {
+	c.webhookReceived.WithLabelValues(eventType).Inc()
+}
+
+// Rejected	实现 webhook.Observer
+func (c *Collector) Rejected(reason string) {
+	c.webhookRejected.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/forwarder"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+func TestCollectorInterceptor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v3/quotations":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"quotationId":"Q1"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v3/orders":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"orderId":"O1","quotationId":"Q1","status":"ASSIGNING_DRIVER"}}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"errors":[{"id":"ERR_TOO_MANY_REQUESTS","message":"slow down"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewCollector("")
+	reg := prometheus.NewRegistry()
+	assert.NoError(t, reg.Register(c))
+
+	cli := lalamove.NewClient(lalamove.Config{Apikey: "pk_test_key", Secret: "sk_test_secret", Country: enum.AREA_CODE_HK}).
+		SetEndpoint(srv.URL).
+		Use(c.Interceptor())
+
+	_, err := cli.GetQuotations(nil)
+	assert.NoError(t, err)
+	_, err = cli.PlaceOrder(nil)
+	assert.NoError(t, err)
+	ok, err := cli.CancelOrder("O1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = cli.GetOrderDetail("O1")
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.quotations.WithLabelValues("HK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.ordersPlaced.WithLabelValues("HK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.ordersCanceled.WithLabelValues("HK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.requests.WithLabelValues("HK", "DELETE", "/v3/orders/{orderId}", "204")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.errors.WithLabelValues("HK", "/v3/orders/{orderId}", "ERR_TOO_MANY_REQUESTS")))
+
+	c.Retry("HK", "/v3/orders/O1")
+	c.RateLimitWait(1500 * time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.retries.WithLabelValues("HK", "/v3/orders/{orderId}")))
+	assert.Equal(t, 1.5, testutil.ToFloat64(c.rateLimitWaitSeconds))
+
+	// 注册后可被抓取
+	count, err := testutil.GatherAndCount(reg, "lalamove_requests_total", "lalamove_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, count)
+}
+
+func TestCollectorDryRun(t *testing.T) {
+	c := NewCollector("")
+	cli := lalamove.NewClient(lalamove.Config{Apikey: "pk_test_key", Secret: "sk_test_secret", Country: enum.AREA_CODE_HK}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		Use(c.Interceptor())
+
+	_, err := cli.CancelOrder("O1")
+	assert.NoError(t, err)
+	_, err = cli.AddPriorityFee("O1", "-5")
+	assert.ErrorIs(t, err, lalamove.ErrInvalidRequest)
+
+	// 演练模式的请求未发送, 不计入指标
+	assert.Equal(t, 0, testutil.CollectAndCount(c.requests))
+	assert.Equal(t, 0, testutil.CollectAndCount(c.errors))
+	assert.Equal(t, 0.0, testutil.ToFloat64(c.ordersCanceled.WithLabelValues("HK")))
+}
+
+func TestCollectorWebhook(t *testing.T) {
+	c := NewCollector("")
+	r := &webhook.Receiver{Secret: "sk_test_secret", Observer: c}
+
+	e := webhook.Event{
+		Timestamp: time.Now().Unix(),
+		EventID: "EVT1",
+		EventType: webhook.EVENT_DRIVER_ASSIGNED,
+		Data: json.RawMessage(`{"driver":{"driverId":"D1"},"order":{"orderId":"O1"}}`),
+	}
+	webhook.Sign("sk_test_secret", "/hook", &e)
+	body, _ := json.Marshal(e)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/other", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.webhookReceived.WithLabelValues(webhook.EVENT_DRIVER_ASSIGNED)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.webhookRejected.WithLabelValues(webhook.REJECT_SIGNATURE)))
+}
+
+func TestCollectorForwardRetry(t *testing.T) {
+	c := NewCollector("")
+	outbox, err := forwarder.OpenOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	assert.NoError(t, err)
+	defer outbox.Close()
+
+	failures := 2
+	f := &forwarder.Forwarder{
+		Sink: forwarder.SinkFunc(func(ctx context.Context, e webhook.Event) error {
+			if failures > 0 {
+				failures--
+				return errors.New("queue unavailable")
+			}
+			return nil
+		}),
+		Outbox: outbox,
+		Backoff: time.Millisecond,
+		OnError: c.ForwardRetry,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- f.Run(ctx) }()
+
+	assert.NoError(t, f.Handle(webhook.Event{EventID: "E1", EventType: webhook.EVENT_ORDER_STATUS_CHANGED}))
+	assert.Eventually(t, func() bool { return len(outbox.Pending()) == 0 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.retries.WithLabelValues("forwarder", webhook.EVENT_ORDER_STATUS_CHANGED)))
+}
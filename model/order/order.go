@@ -1,7 +1,10 @@
 package order
 
 import (
+	"fmt"
+
 	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/util"
 )
 
 type Order struct {
@@ -22,6 +25,28 @@ func (o *Order) AddRecipient(recipient DeliveryDetail) *Order {
 	o.Recipients = append(o.Recipients, recipient)
 	return o
 }
+// NormalizePhones	按市场将发件人及收件人电话转换为 E.164 格式 (如香港 8 位号码补充 +852);
+// 未填写的电话将被忽略
+func (o *Order) NormalizePhones(market string) error {
+	if o.Sender.Phone != "" {
+		phone, err := util.NormalizePhone(o.Sender.Phone, market)
+		if err != nil {
+			return fmt.Errorf("sender: %w", err)
+		}
+		o.Sender.Phone = phone
+	}
+	for i := range o.Recipients {
+		if o.Recipients[i].Phone == "" {
+			continue
+		}
+		phone, err := util.NormalizePhone(o.Recipients[i].Phone, market)
+		if err != nil {
+			return fmt.Errorf("recipient %d: %w", i+1, err)
+		}
+		o.Recipients[i].Phone = phone
+	}
+	return nil
+}
 func (o *Order) DisablePOD() *Order {
 	o.IsPODEnabled = false
 	return o
@@ -1,11 +1,18 @@
 package quotation
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 	
 	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/util"
 )
 
+// ErrTooManyStops	站点数量超过上限 (enum.QUOT_STOPS_MAX); 更多站点请使用 Split 拆分
+var ErrTooManyStops = errors.New("too many stops")
+
 type Quotation struct {
 	ServiceType string `json:"serviceType"`
 	Stops []DeliveryStop `json:"stops"`
@@ -16,6 +23,9 @@ type Quotation struct {
 	SpecialRequests []string `json:"specialRequests,omitempty"`
 	IsRouteOptimized bool `json:"isRouteOptimized"`
 	Item *QuotationItem `json:"item,omitempty"`
+
+	// 添加站点失败的原因 (如超出上限); 见 Err
+	err error
 }
 
 // SetScheduleAt	设置取货时间; 如果是立即下单，请省略
@@ -37,13 +47,33 @@ func (q *Quotation) AddSpecialRequest(strs ...string) *Quotation {
 	}
 	return q
 }
-// AddStop	添加站点 (最少2个, 最多16个)
+// AddStop	添加站点 (最少2个, 最多16个); 超出上限时不添加, 并通过 Err 返回 ErrTooManyStops
 func (q *Quotation) AddStop(stop DeliveryStop) *Quotation {
-	if len(q.Stops) < enum.QUOT_STOPS_MAX {
-		q.Stops = append(q.Stops, stop)
+	if len(q.Stops) >= enum.QUOT_STOPS_MAX {
+		if q.err == nil {
+			q.err = fmt.Errorf("%w: stop %d exceeds %d", ErrTooManyStops, len(q.Stops)+1, enum.QUOT_STOPS_MAX)
+		}
+		return q
 	}
+	q.Stops = append(q.Stops, stop)
 	return q
 }
+// AddStops	添加多个站点; 超出上限时不添加任何站点并返回 ErrTooManyStops
+func (q *Quotation) AddStops(stops ...DeliveryStop) error {
+	if len(q.Stops)+len(stops) > enum.QUOT_STOPS_MAX {
+		return fmt.Errorf("%w: %d + %d exceeds %d", ErrTooManyStops, len(q.Stops), len(stops), enum.QUOT_STOPS_MAX)
+	}
+	q.Stops = append(q.Stops, stops...)
+	return nil
+}
+// Err	返回 AddStop 链式调用中出现的第一个错误
+func (q *Quotation) Err() error {
+	return q.err
+}
+// NormalizePhones	按市场将站点联系电话转换为 E.164 格式; 未填写的电话将被忽略
+func (q *Quotation) NormalizePhones(market string) error {
+	return NormalizeStopPhones(q.Stops, market)
+}
 func (q *Quotation) SenderStop() DeliveryStop {
 	return q.Stops[0]
 }
@@ -77,10 +107,45 @@ type DeliveryStop struct {
 	Remarks string `json:"remarks,omitempty"`
 }
 
+// NormalizeStopPhones	按市场将站点联系电话转换为 E.164 格式; 未填写的电话将被忽略
+func NormalizeStopPhones(stops []DeliveryStop, market string) error {
+	for i := range stops {
+		if stops[i].Phone == "" {
+			continue
+		}
+		phone, err := util.NormalizePhone(stops[i].Phone, market)
+		if err != nil {
+			return fmt.Errorf("stop %d: %w", i, err)
+		}
+		stops[i].Phone = phone
+	}
+	return nil
+}
+
 type Coordinates struct {
 	Lat string `json:"lat"`
 	Lng string `json:"lng"`
 }
+// Float	返回数值形式的经纬度
+func (c Coordinates) Float() (lat, lng float64, err error) {
+	if lat, err = strconv.ParseFloat(c.Lat, 64); err != nil {
+		return
+	}
+	lng, err = strconv.ParseFloat(c.Lng, 64)
+	return
+}
+// DistanceTo	计算到另一坐标的距离 (米)
+func (c Coordinates) DistanceTo(other Coordinates) (float64, error) {
+	lat1, lng1, err := c.Float()
+	if err != nil {
+		return 0, err
+	}
+	lat2, lng2, err := other.Float()
+	if err != nil {
+		return 0, err
+	}
+	return util.Haversine(lat1, lng1, lat2, lng2), nil
+}
 
 type PriceBreakdown struct {
 	Base string `json:"base"`
@@ -0,0 +1,208 @@
+package quotation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+// 返回站点与提交站点坐标相差多少米以内视为同一站点
+const STOP_MATCH_RADIUS = 50.0
+
+// ErrStopMismatch	返回的站点无法与提交的站点对应
+var ErrStopMismatch = errors.New("returned stops do not match submitted stops")
+
+// MatchStops	将报价单返回的站点与提交的站点对应 (路线优化后站点顺序可能改变);
+// 返回每个返回站点对应的提交站点下标. 先按地址及坐标完全匹配, 再按最近坐标匹配
+func MatchStops(submitted, returned []DeliveryStop) ([]int, error) {
+	if len(submitted) != len(returned) {
+		return nil, fmt.Errorf("%w: submitted %d, returned %d", ErrStopMismatch, len(submitted), len(returned))
+	}
+
+	order := make([]int, len(returned))
+	used := make([]bool, len(submitted))
+	for i := range order {
+		order[i] = -1
+	}
+	// 完全匹配
+	for i, r := range returned {
+		for j, s := range submitted {
+			if !used[j] && r.Address == s.Address && sameCoordinates(r.Coordinates, s.Coordinates) {
+				order[i], used[j] = j, true
+				break
+			}
+		}
+	}
+	// 最近坐标匹配
+	for i, r := range returned {
+		if order[i] >= 0 {
+			continue
+		}
+		best, bestDistance := -1, math.MaxFloat64
+		for j, s := range submitted {
+			if used[j] {
+				continue
+			}
+			d, err := r.Coordinates.DistanceTo(s.Coordinates)
+			if err == nil && d < bestDistance {
+				best, bestDistance = j, d
+			}
+		}
+		if best < 0 || bestDistance > STOP_MATCH_RADIUS {
+			return nil, fmt.Errorf("%w: stop %d (%s)", ErrStopMismatch, i, r.Address)
+		}
+		order[i], used[best] = best, true
+	}
+	return order, nil
+}
+
+// sameCoordinates	坐标数值是否相同 (忽略格式差异)
+func sameCoordinates(a, b Coordinates) bool {
+	lat1, lng1, err1 := a.Float()
+	lat2, lng2, err2 := b.Float()
+	if err1 != nil || err2 != nil {
+		return strings.TrimSpace(a.Lat) == strings.TrimSpace(b.Lat) && strings.TrimSpace(a.Lng) == strings.TrimSpace(b.Lng)
+	}
+	return lat1 == lat2 && lng1 == lng2
+}
+
+// StopOrder	返回每个返回站点对应的提交站点下标; 见 MatchStops
+func (qd QuotationDetail) StopOrder(submitted []DeliveryStop) ([]int, error) {
+	return MatchStops(submitted, qd.Stops)
+}
+
+// Savings	路线优化节省的金额 (TotalBeforeOptimization - Total); 未优化时返回 0
+func (p PriceBreakdown) Savings() (float64, error) {
+	if p.TotalBeforeOptimization == "" {
+		return 0, nil
+	}
+	before, err := strconv.ParseFloat(p.TotalBeforeOptimization, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid totalBeforeOptimization %q: %w", p.TotalBeforeOptimization, err)
+	}
+	total, err := strconv.ParseFloat(p.Total, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid total %q: %w", p.Total, err)
+	}
+	return before - total, nil
+}
+
+// OptimizeStops	本地优化站点顺序 (适用于不支持路线优化的市场): 首个站点 (取货点) 固定,
+// 先以最近邻生成路线, 再以 2-opt 及 or-opt 改进. 返回优化后的站点及其对应的原站点下标
+func OptimizeStops(stops []DeliveryStop) ([]DeliveryStop, []int, error) {
+	n := len(stops)
+	if n < enum.QUOT_STOPS_MIN || n > enum.QUOT_STOPS_MAX {
+		return nil, nil, fmt.Errorf("stops must be between %d and %d, got %d", enum.QUOT_STOPS_MIN, enum.QUOT_STOPS_MAX, n)
+	}
+
+	// 距离矩阵
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d, err := stops[i].Coordinates.DistanceTo(stops[j].Coordinates)
+			if err != nil {
+				return nil, nil, fmt.Errorf("stop %d/%d: invalid coordinates: %w", i, j, err)
+			}
+			dist[i][j], dist[j][i] = d, d
+		}
+	}
+
+	// 最近邻
+	route := []int{0}
+	visited := make([]bool, n)
+	visited[0] = true
+	for len(route) < n {
+		last := route[len(route)-1]
+		next := -1
+		for j := 1; j < n; j++ {
+			if !visited[j] && (next < 0 || dist[last][j] < dist[last][next]) {
+				next = j
+			}
+		}
+		route = append(route, next)
+		visited[next] = true
+	}
+
+	length := func(route []int) float64 {
+		total := 0.0
+		for i := 1; i < len(route); i++ {
+			total += dist[route[i-1]][route[i]]
+		}
+		return total
+	}
+	best := length(route)
+	try := func(candidate []int) bool {
+		if l := length(candidate); l < best-1e-6 {
+			route, best = candidate, l
+			return true
+		}
+		return false
+	}
+
+	for improved := true; improved; {
+		improved = false
+		// 2-opt: 反转 route[i..k] (终点不需返回起点)
+		for i := 1; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				candidate := append([]int{}, route...)
+				for a, b := i, k; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				improved = try(candidate) || improved
+			}
+		}
+		// or-opt: 将 1~3 个连续站点移到其他位置
+		for size := 1; size <= 3; size++ {
+			for i := 1; i+size <= n; i++ {
+				segment := append([]int{}, route[i:i+size]...)
+				rest := append(append([]int{}, route[:i]...), route[i+size:]...)
+				for j := 1; j <= len(rest); j++ {
+					if j == i {
+						continue
+					}
+					candidate := append(append(append([]int{}, rest[:j]...), segment...), rest[j:]...)
+					if try(candidate) {
+						improved = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	optimized := make([]DeliveryStop, n)
+	for i, idx := range route {
+		optimized[i] = stops[idx]
+	}
+	return optimized, route, nil
+}
+
+// RouteDistance	按顺序经过各站点的直线距离 (米)
+func RouteDistance(stops []DeliveryStop) (float64, error) {
+	total := 0.0
+	for i := 1; i < len(stops); i++ {
+		d, err := stops[i-1].Coordinates.DistanceTo(stops[i].Coordinates)
+		if err != nil {
+			return 0, err
+		}
+		total += d
+	}
+	return total, nil
+}
+
+// OptimizeRoute	本地优化报价单的站点顺序; 返回优化后各站点对应的原站点下标
+func (q *Quotation) OptimizeRoute() ([]int, error) {
+	stops, order, err := OptimizeStops(q.Stops)
+	if err != nil {
+		return nil, err
+	}
+	q.Stops = stops
+	return order, nil
+}
@@ -0,0 +1,127 @@
+package quotation
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stop(address, lat, lng string) DeliveryStop {
+	return DeliveryStop{Address: address, Coordinates: Coordinates{Lat: lat, Lng: lng}}
+}
+
+func TestMatchStops(t *testing.T) {
+	submitted := []DeliveryStop{
+		stop("Innocentre", "22.3354", "114.1761"),
+		stop("Cyberport", "22.2630", "114.1308"),
+		stop("Canton Rd", "22.2955", "114.1689"),
+	}
+	returned := []DeliveryStop{
+		stop("Innocentre", "22.3354", "114.1761"),
+		// 坐标格式不同
+		stop("Canton Rd", "22.29550", "114.16890"),
+		// 地址被改写, 坐标略有偏差
+		stop("Cyberport, Pok Fu Lam", "22.26301", "114.13081"),
+	}
+	order, err := MatchStops(submitted, returned)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 1}, order)
+
+	qd := QuotationDetail{Quotation: Quotation{Stops: returned}}
+	order, err = qd.StopOrder(submitted)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 1}, order)
+
+	returned[2] = stop("Airport", "22.3080", "113.9185")
+	_, err = MatchStops(submitted, returned)
+	assert.ErrorIs(t, err, ErrStopMismatch)
+	_, err = MatchStops(submitted, returned[:2])
+	assert.ErrorIs(t, err, ErrStopMismatch)
+}
+
+func TestSavings(t *testing.T) {
+	savings, err := PriceBreakdown{Total: "180", TotalBeforeOptimization: "215.5"}.Savings()
+	assert.NoError(t, err)
+	assert.Equal(t, 35.5, savings)
+
+	savings, err = PriceBreakdown{Total: "180"}.Savings()
+	assert.NoError(t, err)
+	assert.Zero(t, savings)
+
+	_, err = PriceBreakdown{Total: "", TotalBeforeOptimization: "215"}.Savings()
+	assert.Error(t, err)
+}
+
+// bruteForce	穷举最短路线距离 (首站固定)
+func bruteForce(stops []DeliveryStop) float64 {
+	best := -1.0
+	var permute func(route []DeliveryStop, rest []DeliveryStop)
+	permute = func(route []DeliveryStop, rest []DeliveryStop) {
+		if len(rest) == 0 {
+			d, _ := RouteDistance(route)
+			if best < 0 || d < best {
+				best = d
+			}
+			return
+		}
+		for i := range rest {
+			next := append(append([]DeliveryStop{}, rest[:i]...), rest[i+1:]...)
+			permute(append(append([]DeliveryStop{}, route...), rest[i]), next)
+		}
+	}
+	permute(stops[:1], stops[1:])
+	return best
+}
+
+func TestOptimizeStops(t *testing.T) {
+	// 同一直线上的站点: 最优顺序为由近及远
+	line := []DeliveryStop{
+		stop("P", "22.30", "114.17"),
+		stop("C", "22.33", "114.17"),
+		stop("A", "22.31", "114.17"),
+		stop("D", "22.34", "114.17"),
+		stop("B", "22.32", "114.17"),
+	}
+	optimized, order, err := OptimizeStops(line)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 4, 1, 3}, order)
+	assert.Equal(t, "D", optimized[4].Address)
+
+	// 随机站点接近穷举结果 (启发式, 允许 5% 误差)
+	r := rand.New(rand.NewSource(42))
+	for n := 0; n < 20; n++ {
+		stops := make([]DeliveryStop, 7)
+		for i := range stops {
+			stops[i] = DeliveryStop{Coordinates: coords(22.2+r.Float64()*0.2, 114.0+r.Float64()*0.3)}
+		}
+		optimized, _, err := OptimizeStops(stops)
+		assert.NoError(t, err)
+		assert.Equal(t, stops[0], optimized[0])
+		got, _ := RouteDistance(optimized)
+		original, _ := RouteDistance(stops)
+		assert.LessOrEqual(t, got, original+1e-6)
+		assert.InDelta(t, bruteForce(stops), got, bruteForce(stops)*0.05)
+	}
+
+	// 最多 16 个站点
+	q := &Quotation{}
+	for i := 0; i < 16; i++ {
+		q.Stops = append(q.Stops, DeliveryStop{Coordinates: coords(22.2+r.Float64()*0.2, 114.0+r.Float64()*0.3)})
+	}
+	first := q.Stops[0]
+	order, err = q.OptimizeRoute()
+	assert.NoError(t, err)
+	assert.Len(t, order, 16)
+	assert.Equal(t, first, q.Stops[0])
+
+	_, _, err = OptimizeStops(append(q.Stops, first))
+	assert.Error(t, err)
+	_, _, err = OptimizeStops([]DeliveryStop{stop("P", "x", "y"), stop("A", "22.3", "114.1")})
+	assert.Error(t, err)
+}
+
+func coords(lat, lng float64) Coordinates {
+	return Coordinates{Lat: strconv.FormatFloat(lat, 'f', 6, 64), Lng: strconv.FormatFloat(lng, 'f', 6, 64)}
+}
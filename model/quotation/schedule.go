@@ -0,0 +1,193 @@
+package quotation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+var (
+	// ErrScheduleWindow	预约时间不在可预约范围内
+	ErrScheduleWindow = errors.New("schedule time outside booking window")
+	// ErrBusinessHours	预约时间不在营业时间内
+	ErrBusinessHours = errors.New("schedule time outside business hours")
+	// ErrUnknownMarket	未知市场
+	ErrUnknownMarket = errors.New("unknown market")
+)
+
+// marketZone	市场时区; 时区数据不可用时使用固定偏移 (以下市场均已不实行夏令时)
+type marketZone struct {
+	name string
+	offset int
+}
+
+// 各市场时区
+var marketZones = map[string]marketZone{
+	enum.AREA_CODE_BR: {"America/Sao_Paulo", -3 * 3600},
+	enum.AREA_CODE_HK: {"Asia/Hong_Kong", 8 * 3600},
+	enum.AREA_CODE_ID: {"Asia/Jakarta", 7 * 3600},
+	enum.AREA_CODE_MY: {"Asia/Kuala_Lumpur", 8 * 3600},
+	enum.AREA_CODE_MX: {"America/Mexico_City", -6 * 3600},
+	enum.AREA_CODE_PH: {"Asia/Manila", 8 * 3600},
+	enum.AREA_CODE_SG: {"Asia/Singapore", 8 * 3600},
+	enum.AREA_CODE_TW: {"Asia/Taipei", 8 * 3600},
+	enum.AREA_CODE_TH: {"Asia/Bangkok", 7 * 3600},
+	enum.AREA_CODE_VN: {"Asia/Ho_Chi_Minh", 7 * 3600},
+}
+
+// MarketLocation	返回市场所在时区
+func MarketLocation(market string) (*time.Location, error) {
+	zone, ok := marketZones[strings.ToUpper(market)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMarket, market)
+	}
+	if loc, err := time.LoadLocation(zone.name); err == nil {
+		return loc, nil
+	}
+	return time.FixedZone(zone.name, zone.offset), nil
+}
+
+// ScheduleWindow	可预约时间范围及营业时间 (市场当地时间)
+type ScheduleWindow struct {
+	// 最少提前预约时间
+	MinAdvance time.Duration
+	// 最多提前预约时间
+	MaxAdvance time.Duration
+	// 营业开始及结束时间 (距当地零点); 均为零表示全天营业, 结束早于开始表示跨午夜
+	Open time.Duration
+	Close time.Duration
+}
+
+// 默认可预约范围: 至少提前 10 分钟, 最多提前 30 天, 全天营业
+var DefaultScheduleWindow = ScheduleWindow{
+	MinAdvance: 10 * time.Minute,
+	MaxAdvance: 30 * 24 * time.Hour,
+}
+
+// ScheduleWindows	各市场可预约范围; 未配置的市场使用 DefaultScheduleWindow
+var ScheduleWindows = map[string]ScheduleWindow{}
+
+// open	当地时间 local 是否在营业时间内
+func (w ScheduleWindow) open(local time.Time) bool {
+	if w.Open == 0 && w.Close == 0 {
+		return true
+	}
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	offset := local.Sub(midnight)
+	if w.Close > w.Open {
+		return offset >= w.Open && offset < w.Close
+	}
+	return offset >= w.Open || offset < w.Close
+}
+
+// Scheduler	按市场当地时间设置及校验预约时间
+type Scheduler struct {
+	Market string
+	// 可预约范围; 为零值时使用市场配置
+	Window ScheduleWindow
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+
+	loc *time.Location
+}
+
+// NewScheduler	创建市场的预约时间工具
+func NewScheduler(market string) (*Scheduler, error) {
+	loc, err := MarketLocation(market)
+	if err != nil {
+		return nil, err
+	}
+	market = strings.ToUpper(market)
+	window, ok := ScheduleWindows[market]
+	if !ok {
+		window = DefaultScheduleWindow
+	}
+	return &Scheduler{Market: market, Window: window, loc: loc}, nil
+}
+
+// Location	市场所在时区
+func (s *Scheduler) Location() *time.Location {
+	return s.loc
+}
+
+// now	当前时间
+func (s *Scheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Local	市场当地时间; 如香港 Local(2024, 3, 1, 9, 30) 为 2024-03-01T01:30:00Z
+func (s *Scheduler) Local(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, s.loc)
+}
+
+// Parse	按 layout 解析市场当地时间; value 带时区时以其时区为准
+func (s *Scheduler) Parse(layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, s.loc)
+}
+
+// In	转换为市场当地时间
+func (s *Scheduler) In(t time.Time) time.Time {
+	return t.In(s.loc)
+}
+
+// Validate	校验预约时间是否在可预约范围及营业时间内
+func (s *Scheduler) Validate(at time.Time) error {
+	now := s.now()
+	local := at.In(s.loc)
+	if earliest := now.Add(s.Window.MinAdvance); at.Before(earliest) {
+		return fmt.Errorf("%w: %s is before %s", ErrScheduleWindow, local.Format(time.RFC3339), earliest.In(s.loc).Format(time.RFC3339))
+	}
+	if s.Window.MaxAdvance > 0 {
+		if latest := now.Add(s.Window.MaxAdvance); at.After(latest) {
+			return fmt.Errorf("%w: %s is after %s", ErrScheduleWindow, local.Format(time.RFC3339), latest.In(s.loc).Format(time.RFC3339))
+		}
+	}
+	if !s.Window.open(local) {
+		return fmt.Errorf("%w: %s (%s)", ErrBusinessHours, local.Format(time.RFC3339), s.Market)
+	}
+	return nil
+}
+
+// Next	不早于 at 的最早可预约时间 (按分钟取整); 超出可预约范围时返回错误
+func (s *Scheduler) Next(at time.Time) (time.Time, error) {
+	if earliest := s.now().Add(s.Window.MinAdvance); at.Before(earliest) {
+		at = earliest
+	}
+	at = at.Truncate(time.Minute)
+	if at.Before(s.now().Add(s.Window.MinAdvance)) {
+		at = at.Add(time.Minute)
+	}
+	// 营业时间内的下一个整分钟; 最多向后查找一天
+	for i := 0; i < 24*60 && !s.Window.open(at.In(s.loc)); i++ {
+		at = at.Add(time.Minute)
+	}
+	return at, s.Validate(at)
+}
+
+// Apply	校验并设置报价单的预约时间
+func (s *Scheduler) Apply(q *Quotation, at time.Time) error {
+	if err := s.Validate(at); err != nil {
+		return err
+	}
+	q.SetScheduleAt(at)
+	return nil
+}
+
+// ParseScheduleAt	解析 ScheduleAt (UTC, ISO 8601); 为空时返回零值
+func ParseScheduleAt(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// ScheduledAt	预约取货时间; 立即下单时返回零值
+func (q Quotation) ScheduledAt() (time.Time, error) {
+	return ParseScheduleAt(q.ScheduleAt)
+}
@@ -0,0 +1,90 @@
+package quotation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+func TestMarketLocation(t *testing.T) {
+	noon := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	offsets := map[string]int{
+		enum.AREA_CODE_BR: -3, enum.AREA_CODE_HK: 8, enum.AREA_CODE_ID: 7, enum.AREA_CODE_MY: 8, enum.AREA_CODE_MX: -6,
+		enum.AREA_CODE_PH: 8, enum.AREA_CODE_SG: 8, enum.AREA_CODE_TW: 8, enum.AREA_CODE_TH: 7, enum.AREA_CODE_VN: 7,
+	}
+	for market, hours := range offsets {
+		loc, err := MarketLocation(market)
+		assert.NoError(t, err, market)
+		_, offset := noon.In(loc).Zone()
+		assert.Equal(t, hours*3600, offset, market)
+	}
+	_, err := MarketLocation("XX")
+	assert.ErrorIs(t, err, ErrUnknownMarket)
+}
+
+func TestSchedulerLocalTime(t *testing.T) {
+	s, err := NewScheduler("hk")
+	assert.NoError(t, err)
+	now := s.Local(2024, 3, 1, 8, 0)
+	s.Now = func() time.Time { return now }
+
+	// 香港上午 9:30 即 UTC 01:30
+	at := s.Local(2024, 3, 1, 9, 30)
+	q := &Quotation{}
+	assert.NoError(t, s.Apply(q, at))
+	assert.Equal(t, "2024-03-01T01:30:00Z", q.ScheduleAt)
+
+	scheduled, err := q.ScheduledAt()
+	assert.NoError(t, err)
+	assert.True(t, scheduled.Equal(at))
+	assert.Equal(t, "09:30", s.In(scheduled).Format("15:04"))
+
+	parsed, err := s.Parse("2006-01-02 15:04", "2024-03-01 09:30")
+	assert.NoError(t, err)
+	assert.True(t, parsed.Equal(at))
+
+	// 圣保罗同一当地时间
+	br, _ := NewScheduler(enum.AREA_CODE_BR)
+	assert.Equal(t, "2024-03-01T12:30:00Z", br.Local(2024, 3, 1, 9, 30).UTC().Format(time.RFC3339))
+
+	immediate, err := (Quotation{}).ScheduledAt()
+	assert.NoError(t, err)
+	assert.True(t, immediate.IsZero())
+	detail := QuotationDetail{Quotation: Quotation{ScheduleAt: "2024-03-01T01:30:00.00Z"}}
+	scheduled, err = detail.ScheduledAt()
+	assert.NoError(t, err)
+	assert.True(t, scheduled.Equal(at))
+}
+
+func TestSchedulerWindow(t *testing.T) {
+	s, _ := NewScheduler(enum.AREA_CODE_TW)
+	now := s.Local(2024, 3, 1, 8, 0)
+	s.Now = func() time.Time { return now }
+
+	assert.ErrorIs(t, s.Validate(now.Add(-time.Hour)), ErrScheduleWindow)
+	assert.ErrorIs(t, s.Validate(now.Add(5*time.Minute)), ErrScheduleWindow)
+	assert.NoError(t, s.Validate(now.Add(time.Hour)))
+	assert.ErrorIs(t, s.Validate(now.Add(31*24*time.Hour)), ErrScheduleWindow)
+
+	// 营业时间 08:00 - 20:00
+	s.Window.Open, s.Window.Close = 8*time.Hour, 20*time.Hour
+	assert.NoError(t, s.Validate(s.Local(2024, 3, 1, 19, 59)))
+	assert.ErrorIs(t, s.Validate(s.Local(2024, 3, 1, 20, 0)), ErrBusinessHours)
+	assert.ErrorIs(t, s.Apply(&Quotation{}, s.Local(2024, 3, 2, 6, 0)), ErrBusinessHours)
+
+	next, err := s.Next(s.Local(2024, 3, 1, 21, 15))
+	assert.NoError(t, err)
+	assert.Equal(t, s.Local(2024, 3, 2, 8, 0), next)
+	next, err = s.Next(now)
+	assert.NoError(t, err)
+	assert.Equal(t, s.Local(2024, 3, 1, 8, 10), next)
+
+	// 跨午夜营业 22:00 - 06:00
+	s.Window.Open, s.Window.Close = 22*time.Hour, 6*time.Hour
+	assert.NoError(t, s.Validate(s.Local(2024, 3, 1, 23, 0)))
+	assert.NoError(t, s.Validate(s.Local(2024, 3, 2, 5, 0)))
+	assert.ErrorIs(t, s.Validate(s.Local(2024, 3, 2, 12, 0)), ErrBusinessHours)
+}
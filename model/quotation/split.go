@@ -0,0 +1,128 @@
+package quotation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+// 每张报价单最多收货站点数量 (首个站点为取货点)
+const SPLIT_RECIPIENTS_MAX = enum.QUOT_STOPS_MAX - 1
+
+// SplitOptions	拆分选项
+type SplitOptions struct {
+	// 每批最多收货站点数量; 为零或超出上限时使用 SPLIT_RECIPIENTS_MAX
+	MaxRecipients int
+	// 是否以 OptimizeStops 本地优化每批站点顺序
+	Optimize bool
+}
+
+// Batch	拆分后的一张报价单
+type Batch struct {
+	Quotation *Quotation
+	// 各收货站点 (Quotation.Stops[1:]) 对应的原收货站点下标
+	Dropoffs []int
+}
+
+// Split	将同一取货点的任意数量收货站点按地理位置拆分为多张报价单 (每张最多 15 个收货站点).
+// 以取货点为中心按方位角扫描 (sweep), 从最大的空隙处切开后均分为最少的批次, 使每批站点
+// 集中在同一方向; 各批次沿用 template 的车型, 语言, 预约时间, 特别要求及物品信息
+func Split(template Quotation, pickup DeliveryStop, dropoffs []DeliveryStop, opts SplitOptions) ([]Batch, error) {
+	if len(dropoffs) == 0 {
+		return nil, fmt.Errorf("split: no dropoffs")
+	}
+	max := opts.MaxRecipients
+	if max <= 0 || max > SPLIT_RECIPIENTS_MAX {
+		max = SPLIT_RECIPIENTS_MAX
+	}
+
+	// 方位角
+	lat0, lng0, err := pickup.Coordinates.Float()
+	if err != nil {
+		return nil, fmt.Errorf("split: pickup: invalid coordinates: %w", err)
+	}
+	angles := make([]float64, len(dropoffs))
+	for i, stop := range dropoffs {
+		lat, lng, err := stop.Coordinates.Float()
+		if err != nil {
+			return nil, fmt.Errorf("split: dropoff %d: invalid coordinates: %w", i, err)
+		}
+		angles[i] = math.Atan2((lng-lng0)*math.Cos(lat0*math.Pi/180), lat-lat0)
+	}
+	order := make([]int, len(dropoffs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return angles[order[a]] < angles[order[b]] })
+
+	// 从最大空隙后开始扫描
+	start, gap := 0, -1.0
+	for i := range order {
+		prev := angles[order[(i+len(order)-1)%len(order)]]
+		d := angles[order[i]] - prev
+		if i == 0 {
+			d += 2 * math.Pi
+		}
+		if d > gap {
+			start, gap = i, d
+		}
+	}
+	order = append(order[start:], order[:start]...)
+
+	// 均分为最少的批次
+	count := (len(order) + max - 1) / max
+	batches := make([]Batch, 0, count)
+	for b, offset := 0, 0; b < count; b++ {
+		size := len(order) / count
+		if b < len(order)%count {
+			size++
+		}
+		indexes := append([]int{}, order[offset:offset+size]...)
+		offset += size
+
+		batch, err := template.batch(pickup, dropoffs, indexes, opts.Optimize)
+		if err != nil {
+			return nil, fmt.Errorf("split: batch %d: %w", b, err)
+		}
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// batch	以 template 为模板构建一批报价单
+func (template Quotation) batch(pickup DeliveryStop, dropoffs []DeliveryStop, indexes []int, optimize bool) (Batch, error) {
+	stops := make([]DeliveryStop, 0, len(indexes)+1)
+	stops = append(stops, pickup)
+	for _, i := range indexes {
+		stops = append(stops, dropoffs[i])
+	}
+	if optimize && len(stops) > enum.QUOT_STOPS_MIN {
+		optimized, route, err := OptimizeStops(stops)
+		if err != nil {
+			return Batch{}, err
+		}
+		reordered := make([]int, len(indexes))
+		for i, idx := range route[1:] {
+			reordered[i] = indexes[idx-1]
+		}
+		stops, indexes = optimized, reordered
+	}
+
+	q := &Quotation{
+		ServiceType: template.ServiceType,
+		Language: template.Language,
+		ScheduleAt: template.ScheduleAt,
+		SpecialRequests: append([]string(nil), template.SpecialRequests...),
+		IsRouteOptimized: template.IsRouteOptimized,
+	}
+	if template.Item != nil {
+		item := *template.Item
+		q.Item = &item
+	}
+	if err := q.AddStops(stops...); err != nil {
+		return Batch{}, err
+	}
+	return Batch{Quotation: q, Dropoffs: indexes}, nil
+}
@@ -0,0 +1,131 @@
+package quotation
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+func TestAddStop(t *testing.T) {
+	q := &Quotation{}
+	for i := 0; i < enum.QUOT_STOPS_MAX; i++ {
+		q.AddStop(DeliveryStop{})
+	}
+	assert.NoError(t, q.Err())
+	assert.Len(t, q.Stops, enum.QUOT_STOPS_MAX)
+
+	// 超出上限时不添加, 通过 Err 返回错误
+	q.AddStop(DeliveryStop{Address: "extra"}).AddStop(DeliveryStop{Address: "extra"})
+	assert.ErrorIs(t, q.Err(), ErrTooManyStops)
+	assert.Len(t, q.Stops, enum.QUOT_STOPS_MAX)
+}
+
+func TestAddStops(t *testing.T) {
+	q := &Quotation{}
+	stops := make([]DeliveryStop, enum.QUOT_STOPS_MAX)
+	assert.NoError(t, q.AddStops(stops[:2]...))
+	assert.NoError(t, q.AddStops(stops[2:]...))
+	assert.Len(t, q.Stops, enum.QUOT_STOPS_MAX)
+
+	// 超出上限时不截断, 返回错误
+	err := q.AddStops(DeliveryStop{Address: "extra"})
+	assert.ErrorIs(t, err, ErrTooManyStops)
+	assert.Len(t, q.Stops, enum.QUOT_STOPS_MAX)
+	q.Stops = q.Stops[:10]
+	assert.ErrorIs(t, q.AddStops(stops[:7]...), ErrTooManyStops)
+	assert.Len(t, q.Stops, 10)
+	assert.NoError(t, q.Err())
+}
+
+func TestSplit(t *testing.T) {
+	// 取货点四周三个方向各一组收货站点
+	pickup := stop("P", "22.300000", "114.170000")
+	r := rand.New(rand.NewSource(7))
+	centers := [][2]float64{{0.05, 0}, {-0.03, 0.04}, {-0.03, -0.04}}
+	dropoffs := []DeliveryStop{}
+	group := map[int]int{}
+	for i := 0; i < 40; i++ {
+		c := centers[i%3]
+		group[i] = i % 3
+		s := DeliveryStop{Coordinates: coords(22.3+c[0]+r.Float64()*0.01, 114.17+c[1]+r.Float64()*0.01)}
+		s.Address = fmt.Sprintf("D%d", i)
+		dropoffs = append(dropoffs, s)
+	}
+
+	item := &QuotationItem{Quantity: "1"}
+	template := Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, Language: enum.LANG_EN_HK, SpecialRequests: []string{"PURCHASE_SERVICE"}, Item: item}
+	batches, err := Split(template, pickup, dropoffs, SplitOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, batches, 3)
+
+	seen := []int{}
+	for _, b := range batches {
+		assert.LessOrEqual(t, len(b.Dropoffs), SPLIT_RECIPIENTS_MAX)
+		assert.GreaterOrEqual(t, len(b.Dropoffs), 13)
+		assert.Equal(t, pickup, b.Quotation.Stops[0])
+		assert.Equal(t, enum.SERVICE_TYPE_MOTORCYCLE, b.Quotation.ServiceType)
+		assert.Equal(t, []string{"PURCHASE_SERVICE"}, b.Quotation.SpecialRequests)
+		assert.Equal(t, item, b.Quotation.Item)
+		assert.NotSame(t, item, b.Quotation.Item)
+
+		// 同一批次集中在同一方向
+		groups := map[int]int{}
+		for i, idx := range b.Dropoffs {
+			assert.Equal(t, dropoffs[idx], b.Quotation.Stops[i+1])
+			groups[group[idx]]++
+		}
+		majority := 0
+		for _, n := range groups {
+			if n > majority {
+				majority = n
+			}
+		}
+		assert.GreaterOrEqual(t, majority, len(b.Dropoffs)-1)
+		seen = append(seen, b.Dropoffs...)
+	}
+	sort.Ints(seen)
+	for i := range seen {
+		assert.Equal(t, i, seen[i])
+	}
+
+	// 本地优化每批站点顺序
+	plain, _ := Split(template, pickup, dropoffs, SplitOptions{MaxRecipients: 10})
+	optimized, err := Split(template, pickup, dropoffs, SplitOptions{MaxRecipients: 10, Optimize: true})
+	assert.NoError(t, err)
+	assert.Len(t, optimized, 4)
+	before, after := 0.0, 0.0
+	for i, b := range optimized {
+		assert.ElementsMatch(t, plain[i].Dropoffs, b.Dropoffs)
+		for j, idx := range b.Dropoffs {
+			assert.Equal(t, dropoffs[idx], b.Quotation.Stops[j+1])
+		}
+		d, _ := RouteDistance(plain[i].Quotation.Stops)
+		before += d
+		d, _ = RouteDistance(b.Quotation.Stops)
+		after += d
+	}
+	assert.Less(t, after, before)
+
+	// 少量站点只有一批; 同方位站点不影响切分
+	single, err := Split(template, pickup, dropoffs[:1], SplitOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, single, 1)
+	assert.Equal(t, []int{0}, single[0].Dropoffs)
+	same, err := Split(template, pickup, []DeliveryStop{dropoffs[0], dropoffs[0], dropoffs[0]}, SplitOptions{MaxRecipients: 2})
+	assert.NoError(t, err)
+	assert.Len(t, same, 2)
+
+	_, err = Split(template, pickup, nil, SplitOptions{})
+	assert.Error(t, err)
+	_, err = Split(template, stop("P", "x", "114.17"), dropoffs, SplitOptions{})
+	assert.Error(t, err)
+	bad := append([]DeliveryStop{}, dropoffs...)
+	bad[5].Coordinates.Lng = ""
+	_, err = Split(template, pickup, bad, SplitOptions{})
+	assert.ErrorContains(t, err, "dropoff 5")
+}
@@ -0,0 +1,375 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/util"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// 报价计费: 起步价及每公里价格
+const (
+	PRICE_BASE = 50.0
+	PRICE_PER_KM = 5.0
+)
+
+// apiError	接口错误
+type apiError struct {
+	status int
+	id string
+	message string
+}
+
+func fail(status int, id, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, id: id, message: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP	处理 API 请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, fail(http.StatusBadRequest, "ERR_INVALID_FIELD", err.Error()))
+		return
+	}
+	if err := s.authorize(r, body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	payload := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeError(w, fail(http.StatusBadRequest, "ERR_INVALID_FIELD", "invalid json: %s", err.Error()))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	status, data, events, apiErr := s.handle(r, payload.Data)
+	s.mu.Unlock()
+	s.publish(events)
+
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if data != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+}
+
+// authorize	校验 Authorization 请求头: hmac {apikey}:{timestamp}:{signature}
+func (s *Server) authorize(r *http.Request, body []byte) *apiError {
+	if s.Secret == "" {
+		return nil
+	}
+	unauthorized := fail(http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Unauthorized")
+
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "hmac ")
+	parts := strings.Split(auth, ":")
+	if len(parts) != 3 || (s.Apikey != "" && parts[0] != s.Apikey) {
+		return unauthorized
+	}
+	message := fmt.Sprintf("%s\r\n%s\r\n%s\r\n\r\n", parts[1], r.Method, r.URL.RequestURI())
+	if r.Method != http.MethodGet {
+		message = message + string(body)
+	}
+	if util.Signature(s.Secret, message) != parts[2] {
+		return unauthorized
+	}
+	return nil
+}
+
+// handle	路由请求; 调用时已持有锁
+func (s *Server) handle(r *http.Request, data json.RawMessage) (int, interface{}, []webhook.Event, *apiError) {
+	now := s.clock.Now()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	id := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	version := "/" + lalamove.Version
+
+	switch r.Method + " " + lalamove.Endpoint(r.URL.Path) {
+	case "POST " + version + "/quotations":
+		q := quotation.Quotation{}
+		if err := json.Unmarshal(data, &q); err != nil {
+			return 0, nil, nil, fail(http.StatusBadRequest, "ERR_INVALID_FIELD", err.Error())
+		}
+		qd, err := s.quote(q, now)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		return http.StatusCreated, qd, nil, nil
+
+	case "GET " + version + "/quotations/{quotationId}":
+		qd, ok := s.quotations[id(2)]
+		if !ok {
+			return 0, nil, nil, fail(http.StatusNotFound, "ERR_QUOTATION_NOT_FOUND", "quotation %s not found", id(2))
+		}
+		return http.StatusOK, qd, nil, nil
+
+	case "POST " + version + "/orders":
+		o := order.Order{}
+		if err := json.Unmarshal(data, &o); err != nil {
+			return 0, nil, nil, fail(http.StatusBadRequest, "ERR_INVALID_FIELD", err.Error())
+		}
+		so, err := s.place(o, now)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		return http.StatusCreated, so.detail, []webhook.Event{s.generator(now).OrderStatusChanged(so.detail, "")}, nil
+
+	case "GET " + version + "/cities":
+		return http.StatusOK, []interface{}{}, nil, nil
+
+	case "GET " + version + "/webhook":
+		return http.StatusOK, map[string]string{"url": s.webhookURL}, nil, nil
+
+	case "PATCH " + version + "/webhook":
+		hook := struct {
+			URL string `json:"url"`
+		}{}
+		json.Unmarshal(data, &hook)
+		if hook.URL == "" {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "url is required")
+		}
+		s.webhookURL = hook.URL
+		return http.StatusOK, hook, nil, nil
+	}
+
+	// 订单相关接口
+	if id(1) != "orders" {
+		return 0, nil, nil, fail(http.StatusNotFound, "ERR_NOT_FOUND", "%s %s not found", r.Method, r.URL.Path)
+	}
+	o, ok := s.orders[id(2)]
+	if !ok {
+		return 0, nil, nil, fail(http.StatusNotFound, "ERR_ORDER_NOT_FOUND", "order %s not found", id(2))
+	}
+	switch r.Method + " " + lalamove.Endpoint(r.URL.Path) {
+	case "GET " + version + "/orders/{orderId}":
+		return http.StatusOK, o.detail, nil, nil
+
+	case "DELETE " + version + "/orders/{orderId}":
+		if o.detail.Status != enum.ORDER_STATUS_ASSIGN && o.detail.Status != enum.ORDER_STATUS_GOING {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_CANCELLATION_FORBIDDEN", "order is %s", o.detail.Status)
+		}
+		return http.StatusNoContent, nil, s.finish(o, now, enum.ORDER_STATUS_CANCELED), nil
+
+	case "PATCH " + version + "/orders/{orderId}":
+		if o.detail.Status != enum.ORDER_STATUS_ASSIGN && o.detail.Status != enum.ORDER_STATUS_GOING {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_STATUS", "order is %s", o.detail.Status)
+		}
+		edit := struct {
+			Stops []quotation.DeliveryStop `json:"stops"`
+		}{}
+		if err := json.Unmarshal(data, &edit); err != nil {
+			return 0, nil, nil, fail(http.StatusBadRequest, "ERR_INVALID_FIELD", err.Error())
+		}
+		if err := validateStops(edit.Stops); err != nil {
+			return 0, nil, nil, err
+		}
+		for i := range edit.Stops {
+			if edit.Stops[i].ID == "" {
+				edit.Stops[i].ID = s.nextID(1800000000000000000)
+			}
+		}
+		o.detail.Stops = edit.Stops
+		o.detail.Distance, o.detail.PriceBreakdown = s.price(edit.Stops, o.detail.PriorityFee)
+		return http.StatusOK, o.detail, []webhook.Event{s.generator(now).OrderEdited(o.detail)}, nil
+
+	case "POST " + version + "/orders/{orderId}/priority-fee":
+		if o.detail.Status != enum.ORDER_STATUS_ASSIGN {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_STATUS", "order is %s", o.detail.Status)
+		}
+		fee := struct {
+			PriorityFee string `json:"priorityFee"`
+		}{}
+		json.Unmarshal(data, &fee)
+		v, err := strconv.ParseFloat(fee.PriorityFee, 64)
+		if err != nil || v <= 0 {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_PRIORITY_FEE", "invalid priority fee %q", fee.PriorityFee)
+		}
+		// 多次添加的小费累加
+		current, _ := strconv.ParseFloat(o.detail.PriorityFee, 64)
+		o.detail.PriorityFee = money(math.Round((current+v)*100) / 100)
+		o.detail.Distance, o.detail.PriceBreakdown = s.price(o.detail.Stops, o.detail.PriorityFee)
+		return http.StatusOK, o.detail, []webhook.Event{s.generator(now).OrderAmountChanged(o.detail)}, nil
+
+	case "GET " + version + "/orders/{orderId}/drivers/{driverId}":
+		if o.driver == nil || o.driver.ID != id(4) {
+			return 0, nil, nil, fail(http.StatusNotFound, "ERR_DRIVER_NOT_FOUND", "driver %s not found", id(4))
+		}
+		d := *o.driver
+		d.Coordinates = s.position(o, now)
+		return http.StatusOK, d, nil, nil
+
+	case "DELETE " + version + "/orders/{orderId}/drivers/{driverId}":
+		if o.driver == nil || o.driver.ID != id(4) {
+			return 0, nil, nil, fail(http.StatusNotFound, "ERR_DRIVER_NOT_FOUND", "driver %s not found", id(4))
+		}
+		if o.detail.Status != enum.ORDER_STATUS_GOING {
+			return 0, nil, nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_STATUS", "order is %s", o.detail.Status)
+		}
+		return http.StatusNoContent, nil, s.unassign(o, now), nil
+	}
+	return 0, nil, nil, fail(http.StatusNotFound, "ERR_NOT_FOUND", "%s %s not found", r.Method, r.URL.Path)
+}
+
+// quote	创建报价单
+func (s *Server) quote(q quotation.Quotation, now time.Time) (*quotation.QuotationDetail, *apiError) {
+	if q.ServiceType == "" {
+		return nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "serviceType is required")
+	}
+	if err := validateStops(q.Stops); err != nil {
+		return nil, err
+	}
+
+	ttl := s.QuotationTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	qd := &quotation.QuotationDetail{
+		ID: s.nextID(1600000000000000000),
+		ExpiresAt: now.Add(ttl).UTC().Format(time.RFC3339),
+		Quotation: q,
+	}
+	qd.Stops = append([]quotation.DeliveryStop{}, q.Stops...)
+	for i := range qd.Stops {
+		qd.Stops[i].ID = s.nextID(1800000000000000000)
+	}
+	// 路线优化: 重排收货站点并返回优化前的总价
+	before := ""
+	if q.IsRouteOptimized {
+		_, original := s.price(qd.Stops, "")
+		before = original.Total
+		qd.Stops, _, _ = quotation.OptimizeStops(qd.Stops)
+	}
+	qd.Distance, qd.PriceBreakdown = s.price(qd.Stops, "")
+	qd.PriceBreakdown.TotalBeforeOptimization = before
+	s.quotations[qd.ID] = qd
+	return qd, nil
+}
+
+// place	下单; 订单使用下一个剧本
+func (s *Server) place(o order.Order, now time.Time) (*simOrder, *apiError) {
+	qd, ok := s.quotations[o.QuotationId]
+	if !ok {
+		return nil, fail(http.StatusNotFound, "ERR_QUOTATION_NOT_FOUND", "quotation %s not found", o.QuotationId)
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, qd.ExpiresAt); err == nil && now.After(expiresAt) {
+		return nil, fail(http.StatusUnprocessableEntity, "ERR_QUOTATION_EXPIRED", "quotation %s expired at %s", qd.ID, qd.ExpiresAt)
+	}
+
+	stops := append([]quotation.DeliveryStop{}, qd.Stops...)
+	if o.Sender.StopId != stops[0].ID {
+		return nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "sender stopId %q does not match quotation", o.Sender.StopId)
+	}
+	stops[0].Name, stops[0].Phone = o.Sender.Name, o.Sender.Phone
+	for _, recipient := range o.Recipients {
+		found := false
+		for i := 1; i < len(stops); i++ {
+			if stops[i].ID == recipient.StopId {
+				stops[i].Name, stops[i].Phone, stops[i].Remarks = recipient.Name, recipient.Phone, recipient.Remarks
+				found = true
+			}
+		}
+		if !found {
+			return nil, fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "recipient stopId %q does not match quotation", recipient.StopId)
+		}
+	}
+
+	sc := s.Default
+	if len(s.scenarios) > 0 {
+		sc, s.scenarios = s.scenarios[0], s.scenarios[1:]
+	}
+	so := &simOrder{
+		detail: order.OrderDetail{
+			ID: s.nextID(1700000000000000000),
+			QuotationId: qd.ID,
+			Status: enum.ORDER_STATUS_ASSIGN,
+			Metadata: o.Metadata,
+			Distance: qd.Distance,
+			Stops: stops,
+			PriceBreakdown: qd.PriceBreakdown,
+		},
+		scenario: sc.withDefaults(),
+		since: now,
+	}
+	so.next = now.Add(so.scenario.AssignAfter)
+	s.orders[so.detail.ID] = so
+	return so, nil
+}
+
+// price	按站点间距离计费
+func (s *Server) price(stops []quotation.DeliveryStop, priorityFee string) (quotation.Distance, quotation.PriceBreakdown) {
+	meters := 0.0
+	for i := 1; i < len(stops); i++ {
+		d, _ := stops[i-1].Coordinates.DistanceTo(stops[i].Coordinates)
+		meters += d
+	}
+	currency := s.Currency
+	if currency == "" {
+		currency = "HKD"
+	}
+
+	extra := math.Round(meters/1000*PRICE_PER_KM*100) / 100
+	total := PRICE_BASE + extra
+	fee, _ := strconv.ParseFloat(priorityFee, 64)
+	return quotation.Distance{Value: strconv.Itoa(int(math.Round(meters))), Unit: "m"}, quotation.PriceBreakdown{
+		Base: money(PRICE_BASE),
+		ExtraMileage: money(extra),
+		TotalExcludePriorityFee: money(total),
+		PriorityFee: priorityFee,
+		Total: money(total + fee),
+		Currency: currency,
+	}
+}
+
+// nextID	生成数字形式的ID
+func (s *Server) nextID(base int64) string {
+	s.seq++
+	return strconv.FormatInt(base+int64(s.seq), 10)
+}
+
+// validateStops	校验站点数量及坐标
+func validateStops(stops []quotation.DeliveryStop) *apiError {
+	if len(stops) < enum.QUOT_STOPS_MIN || len(stops) > enum.QUOT_STOPS_MAX {
+		return fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "stops must be between %d and %d", enum.QUOT_STOPS_MIN, enum.QUOT_STOPS_MAX)
+	}
+	for i, stop := range stops {
+		if _, _, err := stop.Coordinates.Float(); err != nil {
+			return fail(http.StatusUnprocessableEntity, "ERR_INVALID_FIELD", "stop %d: invalid coordinates", i)
+		}
+	}
+	return nil
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"id": err.id, "message": err.message}},
+	})
+}
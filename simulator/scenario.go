@@ -0,0 +1,83 @@
+package simulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// 注入的异常
+const (
+	// 无司机接单, 订单过期
+	FAIL_EXPIRED = "EXPIRED"
+	// 司机接单后拒单
+	FAIL_REJECTED = "REJECTED"
+	// 司机接单后被更换, 订单回到待接单状态并由下一位司机接单
+	FAIL_DRIVER_CHANGE = "DRIVER_CHANGE"
+)
+
+// Scenario	订单模拟剧本; 各阶段耗时为零时使用默认值
+type Scenario struct {
+	// 下单 (或更换司机) 到司机接单的耗时; 默认 1 分钟
+	AssignAfter time.Duration
+	// 司机接单到取货的耗时; 默认 10 分钟
+	PickupAfter time.Duration
+	// 取货到完成配送的耗时; 默认 20 分钟
+	CompleteAfter time.Duration
+
+	// 注入的异常: FAIL_EXPIRED, FAIL_REJECTED, FAIL_DRIVER_CHANGE
+	Failure string
+	// 司机接单后多久发生异常 (FAIL_REJECTED, FAIL_DRIVER_CHANGE); 默认为取货耗时的一半
+	FailAfter time.Duration
+
+	// 依次接单的司机; 不足时自动生成
+	Drivers []driver.DriverDetail
+	// 司机接单时所在位置; 默认为取货点以南约 1 公里
+	DriverStart *quotation.Coordinates
+}
+
+// withDefaults	填充默认值
+func (s Scenario) withDefaults() Scenario {
+	if s.AssignAfter <= 0 {
+		s.AssignAfter = time.Minute
+	}
+	if s.PickupAfter <= 0 {
+		s.PickupAfter = 10 * time.Minute
+	}
+	if s.CompleteAfter <= 0 {
+		s.CompleteAfter = 20 * time.Minute
+	}
+	if s.FailAfter <= 0 || s.FailAfter >= s.PickupAfter {
+		s.FailAfter = s.PickupAfter / 2
+	}
+	return s
+}
+
+// Clock	虚拟时钟; 仅在 Advance/Set 时前进
+type Clock struct {
+	mu sync.Mutex
+	now time.Time
+}
+
+// NewClock	创建虚拟时钟
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now	当前虚拟时间
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set	设置虚拟时间; 早于当前时间时忽略
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
@@ -0,0 +1,363 @@
+// Package simulator	本地模拟 Lalamove API; 在虚拟时钟上按剧本推进订单状态, 移动司机位置,
+// 并向设置的地址推送已签名的 webhook 事件. 用于调度逻辑的端到端测试
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+// simOrder	模拟中的订单
+type simOrder struct {
+	detail order.OrderDetail
+	scenario Scenario
+	// 已接单的司机数
+	assigned int
+	driver *driver.DriverDetail
+	// 司机接单时所在位置
+	start quotation.Coordinates
+	// 当前状态开始时间
+	since time.Time
+	// 下一次状态变更时间; 为零表示已结束
+	next time.Time
+	// 异常发生时间; 为零表示无 (或已发生)
+	failAt time.Time
+	// 订单结束时司机位置
+	frozen *quotation.Coordinates
+}
+
+// Server	模拟的 Lalamove API (实现 http.Handler); 配合 httptest.NewServer 及 Client.SetEndpoint 使用
+type Server struct {
+	// 可选; 校验请求签名 (为空时不校验), 同时用于 webhook 签名
+	Apikey string
+	Secret string
+	// 报价币种; 默认 HKD
+	Currency string
+	// 报价有效期; 默认 5 分钟
+	QuotationTTL time.Duration
+	// 未通过 Script 指定剧本的订单使用的默认剧本
+	Default Scenario
+	// 可选; 推送 webhook 使用的HTTP客户端, 默认超时 10 秒
+	HTTPClient *http.Client
+	// 可选; 每个事件推送后的回调 (未设置webhook地址时 err 为 nil)
+	OnEvent func(e webhook.Event, err error)
+
+	clock *Clock
+	mu sync.Mutex
+	seq int
+	webhookURL string
+	scenarios []Scenario
+	quotations map[string]*quotation.QuotationDetail
+	orders map[string]*simOrder
+	events []webhook.Event
+}
+
+// NewServer	创建模拟服务; clock 为空时从当前时间开始
+func NewServer(clock *Clock) *Server {
+	if clock == nil {
+		clock = NewClock(time.Now())
+	}
+	return &Server{
+		clock: clock,
+		quotations: make(map[string]*quotation.QuotationDetail),
+		orders: make(map[string]*simOrder),
+	}
+}
+
+// Clock	虚拟时钟
+func (s *Server) Clock() *Clock {
+	return s.clock
+}
+
+// SetWebhook	设置 webhook 地址 (同 PATCH /v3/webhook)
+func (s *Server) SetWebhook(url string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhookURL = url
+	return s
+}
+
+// Script	依次为之后创建的订单指定剧本
+func (s *Server) Script(scenarios ...Scenario) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenarios = append(s.scenarios, scenarios...)
+	return s
+}
+
+// Order	查询订单当前状态
+func (s *Server) Order(orderID string) (order.OrderDetail, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[orderID]
+	if !ok {
+		return order.OrderDetail{}, false
+	}
+	return o.detail, true
+}
+
+// Events	已产生的全部 webhook 事件
+func (s *Server) Events() []webhook.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhook.Event{}, s.events...)
+}
+
+// Advance	推进虚拟时钟; 按时间顺序执行期间到期的状态变更并推送事件. 返回执行的状态变更数
+func (s *Server) Advance(d time.Duration) int {
+	target := s.clock.Now().Add(d)
+	n := 0
+	for {
+		s.mu.Lock()
+		o := s.due(target)
+		if o == nil {
+			s.mu.Unlock()
+			break
+		}
+		s.clock.Set(o.next)
+		events := s.step(o, o.next)
+		s.mu.Unlock()
+
+		s.publish(events)
+		n++
+	}
+	s.clock.Set(target)
+	return n
+}
+
+// Run	按真实时间驱动虚拟时钟: 每隔 interval 推进 interval*scale, 直到 ctx 结束
+func (s *Server) Run(ctx context.Context, interval time.Duration, scale float64) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.Advance(time.Duration(float64(interval) * scale))
+		}
+	}
+}
+
+// due	最早到期的订单 (同一时间按订单ID顺序)
+func (s *Server) due(target time.Time) *simOrder {
+	var first *simOrder
+	for _, o := range s.orders {
+		if o.next.IsZero() || o.next.After(target) {
+			continue
+		}
+		if first == nil || o.next.Before(first.next) || (o.next.Equal(first.next) && o.detail.ID < first.detail.ID) {
+			first = o
+		}
+	}
+	return first
+}
+
+// generator	以 at 为事件时间的事件生成器
+func (s *Server) generator(at time.Time) *webhook.Generator {
+	return &webhook.Generator{Apikey: s.Apikey, Secret: s.Secret, Now: func() time.Time { return at }}
+}
+
+// step	执行订单的下一次状态变更; 返回需推送的事件
+func (s *Server) step(o *simOrder, at time.Time) []webhook.Event {
+	sc := o.scenario
+	switch o.detail.Status {
+	case enum.ORDER_STATUS_ASSIGN:
+		if sc.Failure == FAIL_EXPIRED {
+			return s.finish(o, at, enum.ORDER_STATUS_EXPIRED)
+		}
+		return s.assign(o, at)
+
+	case enum.ORDER_STATUS_GOING:
+		if !o.failAt.IsZero() && !at.Before(o.failAt) {
+			o.failAt = time.Time{}
+			if sc.Failure == FAIL_REJECTED {
+				return s.finish(o, at, enum.ORDER_STATUS_REJECTED)
+			}
+			return s.unassign(o, at)
+		}
+		return s.transit(o, at, enum.ORDER_STATUS_PICKUP, at.Add(sc.CompleteAfter))
+
+	case enum.ORDER_STATUS_PICKUP:
+		return s.finish(o, at, enum.ORDER_STATUS_COMPLETED)
+	}
+	o.next = time.Time{}
+	return nil
+}
+
+// transit	变更订单状态
+func (s *Server) transit(o *simOrder, at time.Time, status string, next time.Time) []webhook.Event {
+	previous := o.detail.Status
+	o.detail.Status = status
+	o.since = at
+	o.next = next
+	return []webhook.Event{s.generator(at).OrderStatusChanged(o.detail, previous)}
+}
+
+// finish	订单结束; 司机停留在当前位置
+func (s *Server) finish(o *simOrder, at time.Time, status string) []webhook.Event {
+	if o.driver != nil {
+		position := s.position(o, at)
+		o.frozen = &position
+	}
+	return s.transit(o, at, status, time.Time{})
+}
+
+// assign	由下一位司机接单
+func (s *Server) assign(o *simOrder, at time.Time) []webhook.Event {
+	sc := o.scenario
+	d := s.nextDriver(o)
+	o.driver = &d
+	o.detail.DriverId = d.ID
+	o.assigned++
+
+	o.start = s.driverStart(o)
+	d.Coordinates = o.start
+	events := []webhook.Event{s.generator(at).DriverAssigned(o.detail, d)}
+
+	// 每个订单只注入一次异常
+	if o.assigned == 1 && (sc.Failure == FAIL_REJECTED || sc.Failure == FAIL_DRIVER_CHANGE) {
+		o.failAt = at.Add(sc.FailAfter)
+	}
+	next := at.Add(sc.PickupAfter)
+	if !o.failAt.IsZero() {
+		next = o.failAt
+	}
+	return append(events, s.transit(o, at, enum.ORDER_STATUS_GOING, next)...)
+}
+
+// unassign	更换司机; 订单回到待接单状态
+func (s *Server) unassign(o *simOrder, at time.Time) []webhook.Event {
+	o.driver = nil
+	o.detail.DriverId = ""
+	o.failAt = time.Time{}
+	return s.transit(o, at, enum.ORDER_STATUS_ASSIGN, at.Add(o.scenario.AssignAfter))
+}
+
+// nextDriver	剧本中的下一位司机, 不足时自动生成
+func (s *Server) nextDriver(o *simOrder) driver.DriverDetail {
+	if o.assigned < len(o.scenario.Drivers) {
+		return o.scenario.Drivers[o.assigned]
+	}
+	s.seq++
+	return driver.DriverDetail{
+		ID: strconv.Itoa(80000 + s.seq),
+		PlateNo: fmt.Sprintf("SIM%04d", s.seq),
+		Driver: driver.Driver{Name: fmt.Sprintf("Driver %d", s.seq), Phone: fmt.Sprintf("+8529%07d", s.seq)},
+	}
+}
+
+// driverStart	司机接单时所在位置
+func (s *Server) driverStart(o *simOrder) quotation.Coordinates {
+	if o.scenario.DriverStart != nil {
+		return *o.scenario.DriverStart
+	}
+	pickup := o.detail.Stops[0].Coordinates
+	lat, lng, err := pickup.Float()
+	if err != nil {
+		return pickup
+	}
+	// 取货点以南约 1 公里, 每位司机错开
+	return coordinates(lat-0.009*float64(o.assigned), lng)
+}
+
+// position	司机在 at 时刻的位置: 接单后由接单位置前往取货点, 取货后依次前往各收货点
+func (s *Server) position(o *simOrder, at time.Time) quotation.Coordinates {
+	if o.frozen != nil {
+		return *o.frozen
+	}
+	stops := o.detail.Stops
+	switch o.detail.Status {
+	case enum.ORDER_STATUS_GOING:
+		return along([]quotation.Coordinates{o.start, stops[0].Coordinates}, progress(at.Sub(o.since), o.scenario.PickupAfter))
+	case enum.ORDER_STATUS_PICKUP:
+		path := make([]quotation.Coordinates, 0, len(stops))
+		for _, stop := range stops {
+			path = append(path, stop.Coordinates)
+		}
+		return along(path, progress(at.Sub(o.since), o.scenario.CompleteAfter))
+	case enum.ORDER_STATUS_COMPLETED:
+		return stops[len(stops)-1].Coordinates
+	}
+	return o.start
+}
+
+// publish	记录并推送事件; 未设置webhook地址时只记录
+func (s *Server) publish(events []webhook.Event) {
+	if len(events) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.events = append(s.events, events...)
+	target := s.webhookURL
+	s.mu.Unlock()
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	for _, e := range events {
+		var err error
+		if target != "" {
+			err = webhook.Deliver(context.Background(), client, target, s.Secret, e)
+		}
+		if s.OnEvent != nil {
+			s.OnEvent(e, err)
+		}
+	}
+}
+
+// progress	已用时间占比 (0~1)
+func progress(elapsed, total time.Duration) float64 {
+	if total <= 0 || elapsed >= total {
+		return 1
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(elapsed) / float64(total)
+}
+
+// along	按距离比例返回路径上的位置
+func along(path []quotation.Coordinates, fraction float64) quotation.Coordinates {
+	lengths := make([]float64, len(path))
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		lengths[i], _ = path[i-1].DistanceTo(path[i])
+		total += lengths[i]
+	}
+	if total == 0 {
+		return path[len(path)-1]
+	}
+
+	remaining := fraction * total
+	for i := 1; i < len(path); i++ {
+		if remaining <= lengths[i] {
+			lat1, lng1, _ := path[i-1].Float()
+			lat2, lng2, _ := path[i].Float()
+			f := remaining / lengths[i]
+			return coordinates(lat1+(lat2-lat1)*f, lng1+(lng2-lng1)*f)
+		}
+		remaining -= lengths[i]
+	}
+	return path[len(path)-1]
+}
+
+// coordinates	格式化经纬度 (保留 6 位小数)
+func coordinates(lat, lng float64) quotation.Coordinates {
+	round := func(v float64) string {
+		return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', 6, 64)
+	}
+	return quotation.Coordinates{Lat: round(lat), Lng: round(lng)}
+}
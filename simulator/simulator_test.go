@@ -0,0 +1,288 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/webhook"
+)
+
+const (
+	testApikey = "pk_test_simulator"
+	testSecret = "sk_test_simulator"
+)
+
+// recorder	记录收到的订单状态
+type recorder struct {
+	mu sync.Mutex
+	statuses map[string][]string
+	drivers map[string][]string
+}
+
+func (r *recorder) handle(e webhook.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := e.Decode()
+	if err != nil {
+		return err
+	}
+	switch e.EventType {
+	case webhook.EVENT_ORDER_STATUS_CHANGED:
+		r.statuses[data.Order.ID] = append(r.statuses[data.Order.ID], data.Order.Status)
+	case webhook.EVENT_DRIVER_ASSIGNED:
+		r.drivers[data.Order.ID] = append(r.drivers[data.Order.ID], data.Driver.ID)
+	}
+	return nil
+}
+
+func (r *recorder) Statuses(orderID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statuses[orderID]
+}
+
+// setup	启动模拟服务及 webhook 接收端
+func setup(t *testing.T) (*Server, *lalamove.Client, *recorder) {
+	clock := NewClock(time.Now())
+	sim := NewServer(clock)
+	sim.Apikey, sim.Secret = testApikey, testSecret
+	api := httptest.NewServer(sim)
+	t.Cleanup(api.Close)
+
+	rec := &recorder{statuses: make(map[string][]string), drivers: make(map[string][]string)}
+	hook := httptest.NewServer(&webhook.Receiver{
+		Secret: testSecret,
+		Apikey: testApikey,
+		Tolerance: time.Minute,
+		Now: clock.Now,
+		Handler: rec.handle,
+	})
+	t.Cleanup(hook.Close)
+
+	cli := lalamove.NewClient(lalamove.Config{Apikey: testApikey, Secret: testSecret, Country: enum.AREA_CODE_HK}).SetEndpoint(api.URL)
+	ok, err := cli.SetWebhook(hook.URL + "/lalamove/webhook")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	return sim, cli, rec
+}
+
+func place(t *testing.T, cli *lalamove.Client) *order.OrderDetail {
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, Language: enum.LANG_EN_HK}
+	q.AddStop(quotation.DeliveryStop{Address: "A0", Coordinates: quotation.Coordinates{Lat: "22.3000", Lng: "114.1700"}}).
+		AddStop(quotation.DeliveryStop{Address: "A1", Coordinates: quotation.Coordinates{Lat: "22.3200", Lng: "114.1700"}}).
+		AddStop(quotation.DeliveryStop{Address: "A2", Coordinates: quotation.Coordinates{Lat: "22.3200", Lng: "114.1900"}})
+	assert.NoError(t, q.Err())
+
+	result, err := cli.Book(lalamove.BookRequest{
+		Quotation: q,
+		Sender: order.Contact{Name: "Michal", Phone: "+85238485765"},
+		Recipients: map[int]order.DeliveryDetail{
+			1: {Name: "Katrina", Phone: "+85238485760"},
+			2: {Name: "Rick", Phone: "+85238485761"},
+		},
+	})
+	assert.NoError(t, err)
+	return result.Order
+}
+
+func TestLifecycle(t *testing.T) {
+	sim, cli, rec := setup(t)
+	sim.Default = Scenario{AssignAfter: time.Minute, PickupAfter: 10 * time.Minute, CompleteAfter: 20 * time.Minute}
+
+	od := place(t, cli)
+	assert.Equal(t, enum.ORDER_STATUS_ASSIGN, od.Status)
+	assert.Equal(t, "Katrina", od.Stops[1].Name)
+	assert.Equal(t, "HKD", od.PriceBreakdown.Currency)
+
+	assert.Equal(t, 1, sim.Advance(time.Minute))
+	od, err := cli.GetOrderDetail(od.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, enum.ORDER_STATUS_GOING, od.Status)
+	assert.NotEmpty(t, od.DriverId)
+
+	// 司机由接单位置前往取货点
+	first, err := cli.GetDriverDetail(od.ID, od.DriverId)
+	assert.NoError(t, err)
+	sim.Advance(5 * time.Minute)
+	middle, err := cli.GetDriverDetail(od.ID, od.DriverId)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Coordinates, middle.Coordinates)
+	assert.Equal(t, "22.295500", middle.Coordinates.Lat)
+
+	sim.Advance(5 * time.Minute)
+	atPickup, _ := cli.GetDriverDetail(od.ID, od.DriverId)
+	assert.Equal(t, "22.300000", atPickup.Coordinates.Lat)
+
+	sim.Advance(time.Hour)
+	last, _ := cli.GetDriverDetail(od.ID, od.DriverId)
+	assert.Equal(t, quotation.Coordinates{Lat: "22.320000", Lng: "114.190000"}, last.Coordinates)
+
+	assert.Equal(t, []string{
+		enum.ORDER_STATUS_ASSIGN, enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_PICKUP, enum.ORDER_STATUS_COMPLETED,
+	}, rec.Statuses(od.ID))
+	current, _ := sim.Order(od.ID)
+	assert.Equal(t, enum.ORDER_STATUS_COMPLETED, current.Status)
+}
+
+func TestInjectedFailures(t *testing.T) {
+	sim, cli, rec := setup(t)
+	drivers := []driver.DriverDetail{{ID: "D1"}, {ID: "D2"}}
+	sim.Script(
+		Scenario{Failure: FAIL_EXPIRED},
+		Scenario{Failure: FAIL_REJECTED},
+		Scenario{Failure: FAIL_DRIVER_CHANGE, Drivers: drivers},
+	)
+
+	expired := place(t, cli)
+	rejected := place(t, cli)
+	changed := place(t, cli)
+	sim.Advance(2 * time.Hour)
+
+	assert.Equal(t, []string{enum.ORDER_STATUS_ASSIGN, enum.ORDER_STATUS_EXPIRED}, rec.Statuses(expired.ID))
+	assert.Equal(t, []string{enum.ORDER_STATUS_ASSIGN, enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_REJECTED}, rec.Statuses(rejected.ID))
+	assert.Equal(t, []string{
+		enum.ORDER_STATUS_ASSIGN, enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_ASSIGN,
+		enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_PICKUP, enum.ORDER_STATUS_COMPLETED,
+	}, rec.Statuses(changed.ID))
+	assert.Equal(t, []string{"D1", "D2"}, rec.drivers[changed.ID])
+
+	// 默认剧本
+	normal := place(t, cli)
+	sim.Advance(2 * time.Hour)
+	assert.Equal(t, enum.ORDER_STATUS_COMPLETED, rec.Statuses(normal.ID)[3])
+}
+
+func TestRouteOptimization(t *testing.T) {
+	_, cli, _ := setup(t)
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, IsRouteOptimized: true}
+	q.AddStop(quotation.DeliveryStop{Address: "P", Coordinates: quotation.Coordinates{Lat: "22.3000", Lng: "114.1700"}}).
+		AddStop(quotation.DeliveryStop{Address: "Far", Coordinates: quotation.Coordinates{Lat: "22.3400", Lng: "114.1700"}}).
+		AddStop(quotation.DeliveryStop{Address: "Near", Coordinates: quotation.Coordinates{Lat: "22.3100", Lng: "114.1700"}})
+	assert.NoError(t, q.Err())
+
+	result, err := cli.Book(lalamove.BookRequest{
+		Quotation: q,
+		Sender: order.Contact{Name: "Michal", Phone: "+85238485765"},
+		Recipients: map[int]order.DeliveryDetail{
+			1: {Name: "Far", Phone: "+85238485760"},
+			2: {Name: "Near", Phone: "+85238485761"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 1}, result.StopOrder)
+	assert.Equal(t, "Near", result.Quotation.Stops[1].Address)
+	assert.Equal(t, "Near", result.Order.Stops[1].Name)
+	assert.Equal(t, "Far", result.Order.Stops[2].Name)
+
+	savings, err := result.Quotation.PriceBreakdown.Savings()
+	assert.NoError(t, err)
+	assert.Greater(t, savings, 0.0)
+}
+
+func TestPlan(t *testing.T) {
+	_, cli, _ := setup(t)
+	pickup := quotation.DeliveryStop{Address: "P", Coordinates: quotation.Coordinates{Lat: "22.3000", Lng: "114.1700"}}
+	dropoffs := []quotation.DeliveryStop{}
+	for i := 0; i < 40; i++ {
+		lat := 22.25 + float64(i%8)*0.01
+		lng := 114.12 + float64(i/8)*0.02
+		dropoffs = append(dropoffs, quotation.DeliveryStop{
+			Address: fmt.Sprintf("D%d", i),
+			Coordinates: quotation.Coordinates{Lat: strconv.FormatFloat(lat, 'f', 4, 64), Lng: strconv.FormatFloat(lng, 'f', 4, 64)},
+		})
+	}
+
+	plan, err := cli.Plan(lalamove.PlanRequest{
+		Template: quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE, IsRouteOptimized: true},
+		Pickup: pickup,
+		Dropoffs: dropoffs,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Quotes, 3)
+	assert.Equal(t, "HKD", plan.Currency)
+
+	total := 0.0
+	for _, quote := range plan.Quotes {
+		assert.LessOrEqual(t, len(quote.Dropoffs), quotation.SPLIT_RECIPIENTS_MAX)
+		price, _ := strconv.ParseFloat(quote.Detail.PriceBreakdown.Total, 64)
+		total += price
+	}
+	assert.InDelta(t, total, plan.Total, 1e-6)
+
+	// 路线优化后仍可找到每个原收货站点
+	for i, dropoff := range dropoffs {
+		q, stop, ok := plan.Locate(i)
+		assert.True(t, ok)
+		assert.Equal(t, dropoff.Address, plan.Quotes[q].Detail.Stops[stop].Address)
+	}
+	_, _, ok := plan.Locate(40)
+	assert.False(t, ok)
+}
+
+func TestOrderOperations(t *testing.T) {
+	sim, cli, rec := setup(t)
+
+	// 待接单时可加小费及取消
+	od := place(t, cli)
+	od, err := cli.AddPriorityFee(od.ID, "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", od.PriorityFee)
+	od, err = cli.AddPriorityFee(od.ID, "5.5")
+	assert.NoError(t, err)
+	assert.Equal(t, "15.5", od.PriorityFee)
+	assert.Equal(t, "15.5", od.PriceBreakdown.PriorityFee)
+	ok, err := cli.CancelOrder(od.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = cli.CancelOrder(od.ID)
+	assert.ErrorContains(t, err, "ERR_CANCELLATION_FORBIDDEN")
+
+	// 更换司机后由新司机接单
+	od = place(t, cli)
+	sim.Advance(time.Minute)
+	od, _ = cli.GetOrderDetail(od.ID)
+	previous := od.DriverId
+	ok, err = cli.ChangeDriver(od.ID, previous, enum.RESON_LATE)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = cli.GetDriverDetail(od.ID, previous)
+	assert.ErrorContains(t, err, "ERR_DRIVER_NOT_FOUND")
+	sim.Advance(time.Minute)
+	od, _ = cli.GetOrderDetail(od.ID)
+	assert.Equal(t, enum.ORDER_STATUS_GOING, od.Status)
+	assert.NotEqual(t, previous, od.DriverId)
+	assert.Len(t, rec.drivers[od.ID], 2)
+
+	// 报价单过期
+	q := &quotation.Quotation{ServiceType: enum.SERVICE_TYPE_MOTORCYCLE}
+	q.AddStop(quotation.DeliveryStop{Address: "A0", Coordinates: quotation.Coordinates{Lat: "22.3", Lng: "114.17"}}).
+		AddStop(quotation.DeliveryStop{Address: "A1", Coordinates: quotation.Coordinates{Lat: "22.32", Lng: "114.17"}})
+	assert.NoError(t, q.Err())
+	qd, err := cli.GetQuotations(q)
+	assert.NoError(t, err)
+	sim.Advance(10 * time.Minute)
+	_, err = cli.PlaceOrder(&order.Order{
+		QuotationId: qd.ID,
+		Sender: order.Contact{StopId: qd.Stops[0].ID, Name: "Michal", Phone: "+85238485765"},
+		Recipients: []order.DeliveryDetail{{StopId: qd.Stops[1].ID, Name: "Katrina", Phone: "+85238485760"}},
+	})
+	assert.ErrorContains(t, err, "ERR_QUOTATION_EXPIRED")
+
+	// 签名错误
+	api := httptest.NewServer(sim)
+	defer api.Close()
+	other := lalamove.NewClient(lalamove.Config{Apikey: testApikey, Secret: "sk_other", Country: enum.AREA_CODE_HK}).SetEndpoint(api.URL)
+	_, err = other.GetOrderDetail(od.ID)
+	assert.ErrorContains(t, err, "ERR_UNAUTHORIZED")
+}
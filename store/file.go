@@ -0,0 +1,103 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileStore	文件存储; 事件以 jsonl 格式追加写入文件, 打开时回放重建快照
+type FileStore struct {
+	*MemoryStore
+
+	file *os.File
+}
+
+// OpenFileStore	打开 (或创建) 文件存储
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0664)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		file: file,
+	}
+
+	// 回放历史事件; 崩溃时末尾可能留下未写完的记录, 将其截断后继续追加.
+	// 损坏的记录之后仍有有效记录时视为文件损坏
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset, badOffset int64
+	line, badLine := 0, 0
+	var badErr error
+	terminated := true
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			file.Close()
+			return nil, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		line++
+		start := offset
+		offset += int64(len(data))
+		terminated = data[len(data)-1] == '\n'
+		if text := bytes.TrimSpace(data); len(text) > 0 {
+			e := Event{}
+			if jsonErr := json.Unmarshal(text, &e); jsonErr != nil {
+				if badErr == nil {
+					badOffset, badLine, badErr = start, line, jsonErr
+				}
+			} else if badErr != nil {
+				file.Close()
+				return nil, fmt.Errorf("store: %s line %d: %w", path, badLine, badErr)
+			} else {
+				s.MemoryStore.apply(e)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if badErr != nil {
+		if err := file.Truncate(badOffset); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("store: %s: truncate partial record at line %d: %w", path, badLine, err)
+		}
+	} else if !terminated {
+		// 最后一条记录完整但缺少换行符, 补齐后再追加, 避免与下一条记录写在同一行
+		if _, err := file.Write([]byte{'\n'}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Record	记录事件并写入文件
+func (s *FileStore) Record(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.apply(e)
+	return nil
+}
+
+// Close	关闭文件
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
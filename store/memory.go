@@ -0,0 +1,135 @@
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// MemoryStore	内存存储
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	quotations map[string]*quotation.QuotationDetail
+	orders map[string]*order.OrderDetail
+	events map[string][]Event
+}
+
+// NewMemoryStore	创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		quotations: make(map[string]*quotation.QuotationDetail),
+		orders: make(map[string]*order.OrderDetail),
+		events: make(map[string][]Event),
+	}
+}
+
+// Record	记录事件
+func (s *MemoryStore) Record(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apply(e)
+	return nil
+}
+
+// apply	应用事件到快照 (调用方需持有写锁)
+func (s *MemoryStore) apply(e Event) {
+	if e.Quotation != nil {
+		q := *e.Quotation
+		s.quotations[q.ID] = &q
+	}
+
+	if e.Order != nil {
+		o := *e.Order
+		// 部分接口 (如编辑、小费) 返回的 metadata 可能为空, 沿用已有快照
+		if prev, ok := s.orders[o.ID]; ok && o.Metadata == nil {
+			o.Metadata = prev.Metadata
+		}
+		s.orders[o.ID] = &o
+	}
+
+	if e.Type == EVENT_ORDER_CANCELED {
+		if o, ok := s.orders[e.OrderID]; ok {
+			canceled := *o
+			canceled.Status = enum.ORDER_STATUS_CANCELED
+			s.orders[e.OrderID] = &canceled
+		}
+	}
+
+	if e.OrderID != "" {
+		s.events[e.OrderID] = append(s.events[e.OrderID], e)
+	}
+}
+
+// GetQuotation	按ID查询报价单
+func (s *MemoryStore) GetQuotation(quotationID string) (*quotation.QuotationDetail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q, ok := s.quotations[quotationID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *q
+	return &copied, nil
+}
+
+// GetOrder	按ID查询订单最新快照
+func (s *MemoryStore) GetOrder(orderID string) (*order.OrderDetail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.orders[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *o
+	return &copied, nil
+}
+
+// Events	按订单ID查询事件
+func (s *MemoryStore) Events(orderID string) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]Event, len(s.events[orderID]))
+	copy(events, s.events[orderID])
+	return events, nil
+}
+
+// FindByMetadata	按订单 metadata 键值查询
+func (s *MemoryStore) FindByMetadata(key, value string) ([]*order.OrderDetail, error) {
+	return s.find(func(o *order.OrderDetail) bool {
+		v, ok := o.Metadata[key]
+		return ok && v == value
+	}), nil
+}
+
+// FindByStatus	按订单状态查询
+func (s *MemoryStore) FindByStatus(status string) ([]*order.OrderDetail, error) {
+	return s.find(func(o *order.OrderDetail) bool {
+		return o.Status == status
+	}), nil
+}
+
+// find	按条件查询订单 (按订单ID排序)
+func (s *MemoryStore) find(match func(o *order.OrderDetail) bool) []*order.OrderDetail {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]*order.OrderDetail, 0)
+	for _, o := range s.orders {
+		if match(o) {
+			copied := *o
+			orders = append(orders, &copied)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+	return orders
+}
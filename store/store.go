@@ -0,0 +1,70 @@
+// Package store	报价单、订单及订单事件的持久化存储
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// 事件类型
+const (
+	EVENT_QUOTATION       = "QUOTATION"
+	EVENT_ORDER_PLACED    = "ORDER_PLACED"
+	EVENT_ORDER_EDITED    = "ORDER_EDITED"
+	EVENT_PRIORITY_FEE    = "PRIORITY_FEE"
+	EVENT_ORDER_CANCELED  = "ORDER_CANCELED"
+	EVENT_STATUS_SNAPSHOT = "STATUS_SNAPSHOT"
+	EVENT_ESCALATION      = "ESCALATION"
+	EVENT_DRIVER_CHANGED  = "DRIVER_CHANGED"
+)
+
+// ErrNotFound	记录不存在
+var ErrNotFound = errors.New("store: not found")
+
+// Event	存储事件; 根据事件类型携带报价单或订单快照
+type Event struct {
+	Type string `json:"type"`
+	OrderID string `json:"orderId,omitempty"`
+	QuotationID string `json:"quotationId,omitempty"`
+	Time time.Time `json:"time"`
+
+	Quotation *quotation.QuotationDetail `json:"quotation,omitempty"`
+	Order *order.OrderDetail `json:"order,omitempty"`
+	// 附加信息 (如小费金额、取消原因)
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// OrderStore	订单存储接口
+type OrderStore interface {
+	// Record	记录事件, 并更新对应的报价单/订单快照
+	Record(e Event) error
+
+	// GetQuotation	按ID查询报价单
+	GetQuotation(quotationID string) (*quotation.QuotationDetail, error)
+	// GetOrder	按ID查询订单最新快照
+	GetOrder(orderID string) (*order.OrderDetail, error)
+	// Events	按订单ID查询事件 (按记录顺序)
+	Events(orderID string) ([]Event, error)
+	// FindByMetadata	按订单 metadata 键值查询
+	FindByMetadata(key, value string) ([]*order.OrderDetail, error)
+	// FindByStatus	按订单状态查询
+	FindByStatus(status string) ([]*order.OrderDetail, error)
+}
+
+// QuotationEvent	报价事件
+func QuotationEvent(q *quotation.QuotationDetail) Event {
+	return Event{Type: EVENT_QUOTATION, QuotationID: q.ID, Time: time.Now(), Quotation: q}
+}
+
+// OrderEvent	订单事件 (下单、编辑、小费、状态快照)
+func OrderEvent(eventType string, o *order.OrderDetail) Event {
+	return Event{Type: eventType, OrderID: o.ID, QuotationID: o.QuotationId, Time: time.Now(), Order: o}
+}
+
+// CancelEvent	取消订单事件
+func CancelEvent(orderID string) Event {
+	return Event{Type: EVENT_ORDER_CANCELED, OrderID: orderID, Time: time.Now()}
+}
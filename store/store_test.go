@@ -0,0 +1,129 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+func recordLifecycle(t *testing.T, s OrderStore) {
+	assert.NoError(t, s.Record(QuotationEvent(&quotation.QuotationDetail{ID: "Q1"})))
+	assert.NoError(t, s.Record(OrderEvent(EVENT_ORDER_PLACED, &order.OrderDetail{
+		ID: "O1",
+		QuotationId: "Q1",
+		Status: enum.ORDER_STATUS_ASSIGN,
+		Metadata: map[string]string{"ref": "A-1"},
+	})))
+	assert.NoError(t, s.Record(OrderEvent(EVENT_ORDER_PLACED, &order.OrderDetail{
+		ID: "O2",
+		QuotationId: "Q2",
+		Status: enum.ORDER_STATUS_ASSIGN,
+		Metadata: map[string]string{"ref": "A-2"},
+	})))
+	fee := OrderEvent(EVENT_PRIORITY_FEE, &order.OrderDetail{ID: "O1", Status: enum.ORDER_STATUS_GOING, PriorityFee: "10"})
+	fee.Data = map[string]string{"priorityFee": "10"}
+	assert.NoError(t, s.Record(fee))
+	assert.NoError(t, s.Record(CancelEvent("O2")))
+}
+
+func assertLifecycle(t *testing.T, s OrderStore) {
+	q, err := s.GetQuotation("Q1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Q1", q.ID)
+
+	_, err = s.GetOrder("O3")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	o, err := s.GetOrder("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", o.PriorityFee)
+	// 小费接口未返回 metadata 时沿用下单时的快照
+	assert.Equal(t, "A-1", o.Metadata["ref"])
+
+	events, err := s.Events("O1")
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, EVENT_ORDER_PLACED, events[0].Type)
+		assert.Equal(t, EVENT_PRIORITY_FEE, events[1].Type)
+		assert.Equal(t, "10", events[1].Data["priorityFee"])
+	}
+
+	orders, err := s.FindByMetadata("ref", "A-2")
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, "O2", orders[0].ID)
+	}
+
+	orders, err = s.FindByStatus(enum.ORDER_STATUS_CANCELED)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, "O2", orders[0].ID)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	recordLifecycle(t, s)
+	assertLifecycle(t, s)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+
+	s, err := OpenFileStore(path)
+	assert.NoError(t, err)
+	recordLifecycle(t, s)
+	assertLifecycle(t, s)
+	assert.NoError(t, s.Close())
+
+	// 重新打开后回放事件
+	s, err = OpenFileStore(path)
+	assert.NoError(t, err)
+	defer s.Close()
+	assertLifecycle(t, s)
+}
+
+func TestFileStorePartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	s, err := OpenFileStore(path)
+	assert.NoError(t, err)
+	recordLifecycle(t, s)
+	assert.NoError(t, s.Close())
+	data, _ := os.ReadFile(path)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// 崩溃时未写完的末行被截断, 之后可继续追加
+	assert.NoError(t, os.WriteFile(path, []byte(string(data)+`{"type":"ord`), 0664))
+	s, err = OpenFileStore(path)
+	assert.NoError(t, err)
+	assertLifecycle(t, s)
+	truncated, _ := os.ReadFile(path)
+	assert.Equal(t, data, truncated)
+	assert.NoError(t, s.Close())
+
+	// 中间的损坏记录
+	corrupted := lines[0] + "{bad\n" + strings.Join(lines[1:], "")
+	assert.NoError(t, os.WriteFile(path, []byte(corrupted), 0664))
+	_, err = OpenFileStore(path)
+	assert.ErrorContains(t, err, "line 2")
+
+	// 末行完整但缺少换行符时, 追加前补齐换行符
+	assert.NoError(t, os.WriteFile(path, []byte(strings.TrimSuffix(string(data), "\n")), 0664))
+	s, err = OpenFileStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Record(OrderEvent(EVENT_STATUS_SNAPSHOT, &order.OrderDetail{ID: "O9", Status: enum.ORDER_STATUS_ASSIGN})))
+	assert.NoError(t, s.Close())
+	s, err = OpenFileStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+	assertLifecycle(t, s)
+	_, err = s.GetOrder("O9")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,289 @@
+// Package supervisor	司机监控; 司机迟到或无响应时自动更换司机
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+// Client	订单、司机查询及更换司机接口 (*lalamove.Client 已实现)
+type Client interface {
+	GetOrderDetail(orderID string) (*order.OrderDetail, error)
+	GetDriverDetail(orderID, driverID string) (*driver.DriverDetail, error)
+	ChangeDriver(orderID, driverID, reason string) (bool, error)
+}
+
+// 审计记录动作
+const (
+	ACTION_CHECK   = "CHECK"   // 检查司机位置
+	ACTION_REPLACE = "REPLACE" // 更换司机
+	ACTION_LIMIT   = "LIMIT"   // 已达更换次数上限
+	ACTION_STOP    = "STOP"    // 停止监控 (已取货或订单结束)
+)
+
+// Thresholds	更换司机阈值
+type Thresholds struct {
+	// 超过预计取货时间多久仍未到达取货点视为迟到; 为 0 时不检查迟到
+	LateAfter time.Duration
+	// 后续司机 (如更换后的司机) 自接单起多久内应到达取货点; 为 0 时沿用首位司机的时限
+	// (预计取货时间 - 首次监控到首位司机的时间)
+	PickupWithin time.Duration
+	// 司机位置多久未变化视为无响应; 为 0 时不检查无响应
+	StaleAfter time.Duration
+	// 距取货点多少米以内视为已到达; 默认 150 米
+	ArrivalRadius float64
+	// 位置变化超过多少米视为移动; 默认 30 米
+	MoveTolerance float64
+	// 每个订单最多更换司机次数; 默认 1 次
+	MaxReplacements int
+	// 查询间隔; 默认 30 秒
+	Interval time.Duration
+}
+
+// Audit	审计记录
+type Audit struct {
+	Time time.Time `json:"time"`
+	OrderID string `json:"orderId"`
+	DriverID string `json:"driverId"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	Status string `json:"status"`
+	Coordinates quotation.Coordinates `json:"coordinates"`
+	// 距取货点距离 (米)
+	DistanceToPickup float64 `json:"distanceToPickup"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Supervisor	司机监控器
+type Supervisor struct {
+	Client Client
+	Thresholds Thresholds
+
+	// 可选; 记录更换司机事件
+	Store store.OrderStore
+	// 可选; 每条审计记录回调
+	OnAudit func(a Audit)
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+	// 可选; 等待查询间隔 (用于测试), 默认 time.After
+	After func(d time.Duration) <-chan time.Time
+}
+
+// tracking	当前司机的跟踪状态
+type tracking struct {
+	driverID string
+	last quotation.Coordinates
+	movedAt time.Time
+	// 应到达取货点的时间; 为零时不检查迟到
+	deadline time.Time
+}
+
+// Watch	监控订单司机直到已取货、订单结束、达到更换上限或 ctx 取消.
+// expectedPickupAt 为预计到达取货点时间; 返回完整审计记录
+func (s *Supervisor) Watch(ctx context.Context, orderID string, expectedPickupAt time.Time) ([]Audit, error) {
+	if s.Client == nil {
+		return nil, errors.New("supervisor: client is required")
+	}
+	th := s.Thresholds.withDefaults()
+
+	var (
+		trail = make([]Audit, 0)
+		replaced int
+		cur tracking
+		drivers int
+		allowance = th.PickupWithin
+	)
+	// 首位司机以预计取货时间为准; 后续司机自接单起重新计算
+	deadline := func(assignedAt time.Time) time.Time {
+		drivers++
+		if expectedPickupAt.IsZero() {
+			return time.Time{}
+		}
+		if drivers == 1 {
+			if allowance <= 0 {
+				allowance = expectedPickupAt.Sub(assignedAt)
+			}
+			return expectedPickupAt
+		}
+		if allowance < 0 {
+			allowance = 0
+		}
+		return assignedAt.Add(allowance)
+	}
+	for {
+		od, err := s.Client.GetOrderDetail(orderID)
+		if err != nil {
+			return trail, err
+		}
+
+		switch od.Status {
+		case enum.ORDER_STATUS_GOING:
+			// 司机前往取货点中
+		case enum.ORDER_STATUS_ASSIGN:
+			// 等待新司机接单
+			cur = tracking{}
+		default:
+			trail = s.audit(trail, Audit{OrderID: orderID, DriverID: od.DriverId, Action: ACTION_STOP, Status: od.Status})
+			return trail, nil
+		}
+
+		if od.Status == enum.ORDER_STATUS_GOING && od.DriverId != "" {
+			a, breach, err := s.check(od, &cur, th, deadline)
+			if err != nil {
+				return trail, err
+			}
+			trail = s.audit(trail, a)
+
+			if breach != "" {
+				if replaced >= th.MaxReplacements {
+					trail = s.audit(trail, Audit{
+						OrderID: orderID,
+						DriverID: od.DriverId,
+						Action: ACTION_LIMIT,
+						Reason: breach,
+						Status: od.Status,
+						Detail: fmt.Sprintf("max replacements %d reached", th.MaxReplacements),
+					})
+					return trail, nil
+				}
+
+				ok, err := s.Client.ChangeDriver(orderID, od.DriverId, breach)
+				if err != nil {
+					return trail, err
+				}
+				if ok {
+					replaced++
+					a.Action = ACTION_REPLACE
+					a.Reason = breach
+					a.Time = s.now()
+					trail = s.audit(trail, a)
+					s.record(a, replaced)
+					cur = tracking{}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return trail, ctx.Err()
+		case <-s.after(th.Interval):
+		}
+	}
+}
+
+// check	检查司机位置; 返回审计记录及触发的更换原因 (为空时未触发).
+// deadline 返回新司机应到达取货点的时间
+func (s *Supervisor) check(od *order.OrderDetail, cur *tracking, th Thresholds, deadline func(assignedAt time.Time) time.Time) (Audit, string, error) {
+	now := s.now()
+	d, err := s.Client.GetDriverDetail(od.ID, od.DriverId)
+	if err != nil {
+		return Audit{}, "", err
+	}
+
+	a := Audit{
+		Time: now,
+		OrderID: od.ID,
+		DriverID: od.DriverId,
+		Action: ACTION_CHECK,
+		Status: od.Status,
+		Coordinates: d.Coordinates,
+	}
+	if len(od.Stops) > 0 {
+		if a.DistanceToPickup, err = d.Coordinates.DistanceTo(od.Stops[0].Coordinates); err != nil {
+			return a, "", err
+		}
+	}
+
+	// 新司机或位置发生移动
+	if cur.driverID != od.DriverId {
+		*cur = tracking{driverID: od.DriverId, last: d.Coordinates, movedAt: now, deadline: deadline(now)}
+	} else if moved, err := d.Coordinates.DistanceTo(cur.last); err == nil && moved > th.MoveTolerance {
+		cur.last = d.Coordinates
+		cur.movedAt = now
+	}
+
+	// 已到达取货点
+	if a.DistanceToPickup <= th.ArrivalRadius {
+		return a, "", nil
+	}
+	if th.StaleAfter > 0 && now.Sub(cur.movedAt) >= th.StaleAfter {
+		a.Detail = fmt.Sprintf("no movement for %s", now.Sub(cur.movedAt))
+		return a, enum.RESON_UNRESPONSIVE, nil
+	}
+	if th.LateAfter > 0 && !cur.deadline.IsZero() && now.Sub(cur.deadline) >= th.LateAfter {
+		a.Detail = fmt.Sprintf("late for %s, %.0fm from pickup", now.Sub(cur.deadline), a.DistanceToPickup)
+		return a, enum.RESON_LATE, nil
+	}
+	return a, "", nil
+}
+
+// audit	追加审计记录
+func (s *Supervisor) audit(trail []Audit, a Audit) []Audit {
+	if a.Time.IsZero() {
+		a.Time = s.now()
+	}
+	if s.OnAudit != nil {
+		s.OnAudit(a)
+	}
+	return append(trail, a)
+}
+
+// record	记录更换司机事件
+func (s *Supervisor) record(a Audit, replaced int) {
+	if s.Store == nil {
+		return
+	}
+	s.Store.Record(store.Event{
+		Type: store.EVENT_DRIVER_CHANGED,
+		OrderID: a.OrderID,
+		Time: a.Time,
+		Data: map[string]string{
+			"driverId": a.DriverID,
+			"reason": a.Reason,
+			"replacements": strconv.Itoa(replaced),
+			"lat": a.Coordinates.Lat,
+			"lng": a.Coordinates.Lng,
+			"distanceToPickup": strconv.FormatFloat(a.DistanceToPickup, 'f', 0, 64),
+		},
+	})
+}
+
+func (s *Supervisor) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Supervisor) after(d time.Duration) <-chan time.Time {
+	if s.After != nil {
+		return s.After(d)
+	}
+	return time.After(d)
+}
+
+// withDefaults	填充默认阈值
+func (th Thresholds) withDefaults() Thresholds {
+	if th.ArrivalRadius <= 0 {
+		th.ArrivalRadius = 150
+	}
+	if th.MoveTolerance <= 0 {
+		th.MoveTolerance = 30
+	}
+	if th.MaxReplacements <= 0 {
+		th.MaxReplacements = 1
+	}
+	if th.Interval <= 0 {
+		th.Interval = 30 * time.Second
+	}
+	return th
+}
@@ -0,0 +1,220 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+var pickup = quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"}
+
+// fakeClient	每次查询订单时虚拟时钟前进1分钟
+type fakeClient struct {
+	now time.Time
+	checks int
+	// 各司机每次查询的位置; 位置用完后订单变为已取货
+	routes map[string][]quotation.Coordinates
+	drivers []string
+	changes []string
+}
+
+func (f *fakeClient) GetOrderDetail(orderID string) (*order.OrderDetail, error) {
+	f.now = f.now.Add(time.Minute)
+	od := &order.OrderDetail{
+		ID: orderID,
+		Status: enum.ORDER_STATUS_GOING,
+		DriverId: f.drivers[0],
+		Stops: []quotation.DeliveryStop{{Coordinates: pickup}},
+	}
+	if f.checks >= len(f.routes[od.DriverId]) {
+		od.Status = enum.ORDER_STATUS_PICKUP
+	}
+	return od, nil
+}
+
+func (f *fakeClient) GetDriverDetail(orderID, driverID string) (*driver.DriverDetail, error) {
+	route := f.routes[driverID]
+	c := route[f.checks]
+	f.checks++
+	return &driver.DriverDetail{ID: driverID, Coordinates: c}, nil
+}
+
+func (f *fakeClient) ChangeDriver(orderID, driverID, reason string) (bool, error) {
+	f.changes = append(f.changes, driverID+":"+reason)
+	f.drivers = f.drivers[1:]
+	f.checks = 0
+	return true, nil
+}
+
+func stay(c quotation.Coordinates, n int) []quotation.Coordinates {
+	route := make([]quotation.Coordinates, n)
+	for i := range route {
+		route[i] = c
+	}
+	return route
+}
+
+func approach(n int) []quotation.Coordinates {
+	route := make([]quotation.Coordinates, n)
+	for i := range route {
+		// 每次向取货点靠近约 1.1 公里
+		route[i] = quotation.Coordinates{Lat: fmt.Sprintf("%.4f", 22.3354+0.01*float64(n-1-i)), Lng: pickup.Lng}
+	}
+	return route
+}
+
+func newSupervisor(client *fakeClient, th Thresholds) *Supervisor {
+	return &Supervisor{
+		Client: client,
+		Thresholds: th,
+		Now: func() time.Time { return client.now },
+		// 不实际等待; 虚拟时钟由 fakeClient 推进
+		After: func(d time.Duration) <-chan time.Time {
+			ch := make(chan time.Time, 1)
+			ch <- client.now
+			return ch
+		},
+	}
+}
+
+func TestReplaceUnresponsive(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	far := quotation.Coordinates{Lat: "22.4354", Lng: "114.1761"}
+	client := &fakeClient{
+		now: start,
+		drivers: []string{"D1", "D2"},
+		routes: map[string][]quotation.Coordinates{
+			"D1": stay(far, 10),
+			"D2": approach(4),
+		},
+	}
+	s := store.NewMemoryStore()
+	sv := newSupervisor(client, Thresholds{StaleAfter: 5 * time.Minute})
+	sv.Store = s
+
+	trail, err := sv.Watch(context.Background(), "O1", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"D1:" + enum.RESON_UNRESPONSIVE}, client.changes)
+
+	last := trail[len(trail)-1]
+	assert.Equal(t, ACTION_STOP, last.Action)
+	assert.Equal(t, enum.ORDER_STATUS_PICKUP, last.Status)
+
+	replaces := 0
+	for _, a := range trail {
+		if a.Action == ACTION_REPLACE {
+			replaces++
+			assert.Equal(t, "D1", a.DriverID)
+			assert.Greater(t, a.DistanceToPickup, float64(10000))
+		}
+	}
+	assert.Equal(t, 1, replaces)
+
+	events, _ := s.Events("O1")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, store.EVENT_DRIVER_CHANGED, events[0].Type)
+		assert.Equal(t, enum.RESON_UNRESPONSIVE, events[0].Data["reason"])
+	}
+}
+
+func TestReplaceLateWithLimit(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	client := &fakeClient{
+		now: start,
+		drivers: []string{"D1", "D2", "D3"},
+		routes: map[string][]quotation.Coordinates{
+			"D1": approach(30),
+			"D2": approach(30),
+			"D3": approach(30),
+		},
+	}
+	sv := newSupervisor(client, Thresholds{LateAfter: 2 * time.Minute, MaxReplacements: 1})
+
+	trail, err := sv.Watch(context.Background(), "O1", start)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"D1:" + enum.RESON_LATE}, client.changes)
+	assert.Equal(t, ACTION_LIMIT, trail[len(trail)-1].Action)
+	assert.Equal(t, "D2", trail[len(trail)-1].DriverID)
+}
+
+func TestNoReplaceWhenArrived(t *testing.T) {
+	start := time.Now()
+	client := &fakeClient{
+		now: start,
+		drivers: []string{"D1"},
+		routes: map[string][]quotation.Coordinates{
+			"D1": stay(pickup, 10),
+		},
+	}
+	sv := newSupervisor(client, Thresholds{LateAfter: time.Minute, StaleAfter: time.Minute})
+	waits := make([]time.Duration, 0)
+	after := sv.After
+	sv.After = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		return after(d)
+	}
+
+	trail, err := sv.Watch(context.Background(), "O1", start)
+	assert.NoError(t, err)
+	assert.Empty(t, client.changes)
+	// 按默认查询间隔等待
+	if assert.NotEmpty(t, waits) {
+		assert.Equal(t, 30*time.Second, waits[0])
+	}
+	assert.Equal(t, ACTION_STOP, trail[len(trail)-1].Action)
+}
+
+func TestReplacementDeadlineFromAssignment(t *testing.T) {
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	client := &fakeClient{
+		now: start,
+		drivers: []string{"D1", "D2", "D3"},
+		routes: map[string][]quotation.Coordinates{
+			"D1": approach(30),
+			// 新司机自接单起 4 分钟内到达
+			"D2": approach(5),
+		},
+	}
+	sv := newSupervisor(client, Thresholds{LateAfter: 2 * time.Minute, MaxReplacements: 2})
+
+	// 首位司机有 4 分钟到达取货点, 更换后的司机沿用同样的时限
+	trail, err := sv.Watch(context.Background(), "O1", start.Add(5*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"D1:" + enum.RESON_LATE}, client.changes)
+	assert.Equal(t, ACTION_STOP, trail[len(trail)-1].Action)
+	assert.Equal(t, enum.ORDER_STATUS_PICKUP, trail[len(trail)-1].Status)
+
+	// 指定后续司机的时限
+	client = &fakeClient{
+		now: start,
+		drivers: []string{"D1", "D2", "D3"},
+		routes: map[string][]quotation.Coordinates{
+			"D1": approach(30),
+			"D2": approach(30),
+			"D3": approach(30),
+		},
+	}
+	sv = newSupervisor(client, Thresholds{LateAfter: 2 * time.Minute, PickupWithin: 10 * time.Minute, MaxReplacements: 2})
+	trail, err = sv.Watch(context.Background(), "O1", start.Add(5*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"D1:" + enum.RESON_LATE, "D2:" + enum.RESON_LATE}, client.changes)
+	replacedAt := []time.Time{}
+	for _, a := range trail {
+		if a.Action == ACTION_REPLACE {
+			replacedAt = append(replacedAt, a.Time)
+		}
+	}
+	// D2 自接单 (更换后下一次查询) 起 10 分钟 + 2 分钟宽限后才被更换
+	if assert.Len(t, replacedAt, 2) {
+		assert.Equal(t, 13*time.Minute, replacedAt[1].Sub(replacedAt[0]))
+	}
+}
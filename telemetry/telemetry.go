@@ -0,0 +1,182 @@
+// Package telemetry	OpenTelemetry 链路追踪及指标; 以拦截器方式接入 lalamove.Client
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+)
+
+// 仪表名称
+const instrumentationName = "github.com/eddielau42/lalamove-go-api/telemetry"
+
+// 属性名称
+const (
+	ATTR_ENDPOINT     = attribute.Key("lalamove.endpoint")
+	ATTR_MARKET       = attribute.Key("lalamove.market")
+	ATTR_REQUEST_ID   = attribute.Key("lalamove.request_id")
+	ATTR_QUOTATION_ID = attribute.Key("lalamove.quotation_id")
+	ATTR_ORDER_ID     = attribute.Key("lalamove.order_id")
+	ATTR_ERROR_ID     = attribute.Key("lalamove.error_id")
+	ATTR_DRY_RUN      = attribute.Key("lalamove.dry_run")
+	ATTR_METHOD       = attribute.Key("http.request.method")
+	ATTR_STATUS_CODE  = attribute.Key("http.response.status_code")
+)
+
+// Options	链路追踪及指标配置; 为空时使用 otel 全局配置
+type Options struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider metric.MeterProvider
+	Propagator propagation.TextMapPropagator
+}
+
+// instruments	指标
+type instruments struct {
+	duration metric.Float64Histogram
+	requests metric.Int64Counter
+	errors metric.Int64Counter
+}
+
+// Interceptor	返回为每次API调用创建 span 并记录耗时及错误指标的拦截器
+func Interceptor(opts Options) (lalamove.Interceptor, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+	if opts.Propagator == nil {
+		opts.Propagator = otel.GetTextMapPropagator()
+	}
+
+	tracer := opts.TracerProvider.Tracer(instrumentationName)
+	meter := opts.MeterProvider.Meter(instrumentationName)
+
+	var (
+		inst instruments
+		err error
+	)
+	if inst.duration, err = meter.Float64Histogram("lalamove.client.duration",
+		metric.WithDescription("Duration of Lalamove API calls"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+	if inst.requests, err = meter.Int64Counter("lalamove.client.requests",
+		metric.WithDescription("Number of Lalamove API calls"),
+	); err != nil {
+		return nil, err
+	}
+	if inst.errors, err = meter.Int64Counter("lalamove.client.errors",
+		metric.WithDescription("Number of failed Lalamove API calls"),
+	); err != nil {
+		return nil, err
+	}
+
+	return func(next lalamove.RoundTrip) lalamove.RoundTrip {
+		return func(call *lalamove.Call) (*lalamove.APIResult, error) {
+			endpoint := lalamove.Endpoint(call.URI)
+			base := []attribute.KeyValue{
+				ATTR_ENDPOINT.String(endpoint),
+				ATTR_MARKET.String(call.Market),
+				ATTR_METHOD.String(call.Method),
+			}
+
+			ctx, span := tracer.Start(call.Context, "lalamove "+call.Method+" "+endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(base...),
+			)
+			defer span.End()
+
+			// 传递链路上下文
+			call.Context = ctx
+			opts.Propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+
+			start := time.Now()
+			result, err := next(call)
+			elapsed := time.Since(start).Seconds()
+
+			// 演练模式的请求未发送, 只标记 span, 不计入指标
+			if lalamove.IsDryRunResult(result, err) {
+				span.SetAttributes(ATTR_DRY_RUN.Bool(true))
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+				return result, err
+			}
+
+			metricAttrs := append([]attribute.KeyValue{}, base...)
+			errorType := ""
+			if err != nil {
+				errorType = "transport"
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			if result != nil {
+				span.SetAttributes(ids(call, result)...)
+				if result.Response != nil {
+					status := result.Response.StatusCode
+					span.SetAttributes(ATTR_STATUS_CODE.Int(status))
+					metricAttrs = append(metricAttrs, ATTR_STATUS_CODE.Int(status))
+					if status >= http.StatusBadRequest {
+						errorType = result.ErrorID()
+						if errorType == "" {
+							errorType = http.StatusText(status)
+						}
+						span.SetAttributes(ATTR_ERROR_ID.String(errorType))
+						span.SetStatus(codes.Error, errorType)
+					}
+				}
+			}
+
+			inst.requests.Add(ctx, 1, metric.WithAttributes(metricAttrs...))
+			inst.duration.Record(ctx, elapsed, metric.WithAttributes(metricAttrs...))
+			if errorType != "" {
+				inst.errors.Add(ctx, 1, metric.WithAttributes(append(metricAttrs, ATTR_ERROR_ID.String(errorType))...))
+			}
+			return result, err
+		}
+	}, nil
+}
+
+// ids	返回请求ID、报价单ID及订单ID属性
+func ids(call *lalamove.Call, result *lalamove.APIResult) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 3)
+	if result.ReqID != "" {
+		attrs = append(attrs, ATTR_REQUEST_ID.String(result.ReqID))
+	}
+
+	data := struct{
+		Data struct{
+			QuotationID string `json:"quotationId"`
+			OrderID string `json:"orderId"`
+		} `json:"data"`
+	}{}
+	json.Unmarshal(result.Body, &data)
+
+	quotationID := data.Data.QuotationID
+	orderID := data.Data.OrderID
+	if quotationID == "" {
+		quotationID = lalamove.PathID(call.URI, "quotations")
+	}
+	if orderID == "" {
+		orderID = lalamove.PathID(call.URI, "orders")
+	}
+	if quotationID != "" {
+		attrs = append(attrs, ATTR_QUOTATION_ID.String(quotationID))
+	}
+	if orderID != "" {
+		attrs = append(attrs, ATTR_ORDER_ID.String(orderID))
+	}
+	return attrs
+}
@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/lalamove"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestInterceptor(t *testing.T) {
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		switch r.URL.Path {
+		case "/v3/quotations/Q1":
+			w.Write([]byte(`{"data":{"quotationId":"Q1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"id":"ERR_ORDER_NOT_FOUND","message":"not found"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	interceptor, err := Interceptor(Options{
+		TracerProvider: tp,
+		MeterProvider: mp,
+		Propagator: propagation.TraceContext{},
+	})
+	assert.NoError(t, err)
+
+	cli := lalamove.NewClient(lalamove.Config{Apikey: "pk_test_key", Secret: "sk_test_secret", Country: enum.AREA_CODE_HK}).
+		SetEndpoint(srv.URL).
+		Use(interceptor)
+
+	// 父 span 通过 WithContext 传递
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	_, err = cli.WithContext(ctx).GetQuotationDetail("Q1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, traceparent)
+
+	_, err = cli.GetOrderDetail("O404")
+	assert.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 3) {
+		quote := spans[0]
+		assert.Equal(t, "lalamove GET /v3/quotations/{quotationId}", quote.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), quote.Parent().SpanID())
+		assert.Equal(t, "HK", spanAttr(quote, ATTR_MARKET).AsString())
+		assert.Equal(t, "Q1", spanAttr(quote, ATTR_QUOTATION_ID).AsString())
+		assert.Equal(t, int64(200), spanAttr(quote, ATTR_STATUS_CODE).AsInt64())
+		assert.NotEmpty(t, spanAttr(quote, ATTR_REQUEST_ID).AsString())
+		assert.Equal(t, codes.Unset, quote.Status().Code)
+
+		missing := spans[1]
+		assert.Equal(t, "lalamove GET /v3/orders/{orderId}", missing.Name())
+		assert.Equal(t, "O404", spanAttr(missing, ATTR_ORDER_ID).AsString())
+		assert.Equal(t, "ERR_ORDER_NOT_FOUND", spanAttr(missing, ATTR_ERROR_ID).AsString())
+		assert.Equal(t, codes.Error, missing.Status().Code)
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	found := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				assert.Len(t, data.DataPoints, 2)
+			case metricdata.Sum[int64]:
+				if m.Name == "lalamove.client.errors" && assert.Len(t, data.DataPoints, 1) {
+					id, _ := data.DataPoints[0].Attributes.Value(ATTR_ERROR_ID)
+					assert.Equal(t, "ERR_ORDER_NOT_FOUND", id.AsString())
+					endpoint, _ := data.DataPoints[0].Attributes.Value(ATTR_ENDPOINT)
+					assert.Equal(t, "/v3/orders/{orderId}", endpoint.AsString())
+				}
+			}
+		}
+	}
+	assert.True(t, found["lalamove.client.duration"])
+	assert.True(t, found["lalamove.client.requests"])
+	assert.True(t, found["lalamove.client.errors"])
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "/v3/quotations", lalamove.Endpoint("/v3/quotations"))
+	assert.Equal(t, "/v3/orders/{orderId}/drivers/{driverId}", lalamove.Endpoint("/v3/orders/O1/drivers/D1"))
+	assert.Equal(t, "/v3/orders/{orderId}/priority-fee", lalamove.Endpoint("/v3/orders/O1/priority-fee"))
+
+	assert.Equal(t, "O1", lalamove.PathID("/v3/orders/O1/drivers/D1", "orders"))
+	assert.Equal(t, "D1", lalamove.PathID("/v3/orders/O1/drivers/D1?x=1", "drivers"))
+	assert.Equal(t, "", lalamove.PathID("/v3/quotations", "quotations"))
+}
+
+func TestInterceptorDryRun(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	interceptor, err := Interceptor(Options{TracerProvider: tp, MeterProvider: mp})
+	assert.NoError(t, err)
+
+	cli := lalamove.NewClient(lalamove.Config{Apikey: "pk_test_key", Secret: "sk_test_secret", Country: enum.AREA_CODE_HK}).
+		SetEndpoint("http://127.0.0.1:0").
+		DryRun(true).
+		Use(interceptor)
+
+	ok, err := cli.CancelOrder("O1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 演练模式的请求只标记 span, 不计入指标
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.True(t, spanAttr(spans[0], ATTR_DRY_RUN).AsBool())
+	}
+	rm := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		assert.Empty(t, sm.Metrics)
+	}
+}
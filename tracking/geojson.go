@@ -0,0 +1,104 @@
+package tracking
+
+import (
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// FeatureCollection	GeoJSON 要素集合
+type FeatureCollection struct {
+	Type string `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature	GeoJSON 要素
+type Feature struct {
+	Type string `json:"type"`
+	Geometry Geometry `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry	GeoJSON 几何对象; Point 时 Coordinates 为 [lng, lat], LineString 时为 [[lng, lat], ...]
+type Geometry struct {
+	Type string `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// GeoJSON	返回订单轨迹 (LineString; 只有一个位置时为 Point) 及站点 (Point) 的 GeoJSON
+func (t *Tracker) GeoJSON(orderID string) (*FeatureCollection, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tr, ok := t.trails[orderID]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+
+	fc := &FeatureCollection{
+		Type: "FeatureCollection",
+		Features: make([]Feature, 0, len(tr.stops)+1),
+	}
+
+	line := make([][]float64, 0, len(tr.crumbs))
+	times := make([]string, 0, len(tr.crumbs))
+	for _, crumb := range tr.crumbs {
+		if pos, ok := position(crumb.Coordinates); ok {
+			line = append(line, pos)
+			times = append(times, crumb.Time.UTC().Format(time.RFC3339))
+		}
+	}
+	trailProps := map[string]interface{}{
+		"orderId": orderID,
+		"times": times,
+	}
+	if tr.last != nil {
+		trailProps["driverId"] = tr.last.DriverID
+		trailProps["status"] = tr.last.Status
+		trailProps["speed"] = tr.last.Speed
+		trailProps["nextStop"] = tr.last.NextStop
+		trailProps["distanceRemaining"] = tr.last.DistanceRemaining
+	}
+	// LineString 至少需要 2 个位置; 只有 1 个位置时输出 Point, 没有位置时省略
+	switch {
+	case len(line) >= 2:
+		fc.Features = append(fc.Features, Feature{
+			Type: "Feature",
+			Geometry: Geometry{Type: "LineString", Coordinates: line},
+			Properties: trailProps,
+		})
+	case len(line) == 1:
+		fc.Features = append(fc.Features, Feature{
+			Type: "Feature",
+			Geometry: Geometry{Type: "Point", Coordinates: line[0]},
+			Properties: trailProps,
+		})
+	}
+
+	for i, stop := range tr.stops {
+		pos, ok := position(stop.Coordinates)
+		if !ok {
+			continue
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type: "Feature",
+			Geometry: Geometry{Type: "Point", Coordinates: pos},
+			Properties: map[string]interface{}{
+				"stopId": stop.ID,
+				"index": i,
+				"address": stop.Address,
+				"reached": tr.reached[i],
+			},
+		})
+	}
+	return fc, nil
+}
+
+// position	转换为 GeoJSON 坐标 [lng, lat]
+func position(c quotation.Coordinates) ([]float64, bool) {
+	lat, lng, err := c.Float()
+	if err != nil {
+		return nil, false
+	}
+	return []float64{lng, lat}, true
+}
@@ -0,0 +1,71 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GeoJSONHandler	返回订单轨迹 GeoJSON; 请求参数: orderId
+func (t *Tracker) GeoJSONHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fc, err := t.GeoJSON(r.URL.Query().Get("orderId"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		json.NewEncoder(w).Encode(fc)
+	})
+}
+
+// SSEHandler	以 Server-Sent Events 推送订单跟踪快照; 请求参数: orderId.
+// 连接建立后先推送最近一次快照, 订单结束后关闭连接
+func (t *Tracker) SSEHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		orderID := r.URL.Query().Get("orderId")
+		ch, cancel, err := t.Subscribe(orderID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		if last, _ := t.Last(orderID); last != nil {
+			writeEvent(w, *last)
+		}
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case snap, ok := <-ch:
+				if !ok {
+					fmt.Fprint(w, "event: end\ndata: {}\n\n")
+					flusher.Flush()
+					return
+				}
+				writeEvent(w, snap)
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// writeEvent	写入一条 SSE 事件
+func writeEvent(w http.ResponseWriter, snap Snapshot) {
+	data, _ := json.Marshal(snap)
+	fmt.Fprintf(w, "event: position\ndata: %s\n\n", data)
+}
@@ -0,0 +1,346 @@
+// Package tracking	司机实时位置跟踪; 记录订单轨迹, 计算速度及剩余距离, 输出 GeoJSON 及 SSE 推送
+package tracking
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+// Client	订单及司机查询接口 (*lalamove.Client 已实现)
+type Client interface {
+	GetOrderDetail(orderID string) (*order.OrderDetail, error)
+	GetDriverDetail(orderID, driverID string) (*driver.DriverDetail, error)
+}
+
+// ErrNotTracked	订单未在跟踪中
+var ErrNotTracked = errors.New("tracking: order not tracked")
+
+// Breadcrumb	轨迹点
+type Breadcrumb struct {
+	Coordinates quotation.Coordinates `json:"coordinates"`
+	Time time.Time `json:"time"`
+}
+
+// Snapshot	订单跟踪快照
+type Snapshot struct {
+	OrderID string `json:"orderId"`
+	DriverID string `json:"driverId"`
+	Status string `json:"status"`
+	Position Breadcrumb `json:"position"`
+	// 速度 (米/秒), 根据最近两个轨迹点计算
+	Speed float64 `json:"speed"`
+	// 下一站点下标 (-1 表示已全部到达)
+	NextStop int `json:"nextStop"`
+	// 距下一站点距离 (米)
+	DistanceRemaining float64 `json:"distanceRemaining"`
+}
+
+// trail	订单轨迹
+type trail struct {
+	stops []quotation.DeliveryStop
+	crumbs []Breadcrumb
+	reached map[int]bool
+	last *Snapshot
+	// 订单已结束, 不再更新
+	finished bool
+}
+
+// Tracker	司机位置跟踪器
+type Tracker struct {
+	Client Client
+	// 查询间隔; 默认 10 秒
+	Interval time.Duration
+	// 距站点多少米以内视为已到达; 默认 100 米
+	ArrivalRadius float64
+	// 每个订单保留的最大轨迹点数; 默认 1000
+	MaxCrumbs int
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+
+	mu sync.RWMutex
+	trails map[string]*trail
+	subs map[string]map[chan Snapshot]struct{}
+}
+
+// NewTracker	创建跟踪器
+func NewTracker(client Client) *Tracker {
+	return &Tracker{
+		Client: client,
+		trails: make(map[string]*trail),
+		subs: make(map[string]map[chan Snapshot]struct{}),
+	}
+}
+
+// Track	开始跟踪订单
+func (t *Tracker) Track(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.trails[orderID]; !ok {
+		t.trails[orderID] = &trail{reached: make(map[int]bool)}
+	}
+}
+
+// Untrack	停止跟踪订单并清除轨迹
+func (t *Tracker) Untrack(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.trails, orderID)
+	t.closeSubs(orderID)
+}
+
+// closeSubs	关闭订单的所有订阅 (调用方需持有写锁)
+func (t *Tracker) closeSubs(orderID string) {
+	for ch := range t.subs[orderID] {
+		close(ch)
+	}
+	delete(t.subs, orderID)
+}
+
+// Orders	返回跟踪中且未结束的订单ID
+func (t *Tracker) Orders() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	ids := make([]string, 0, len(t.trails))
+	for id, tr := range t.trails {
+		if !tr.finished {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Run	定时更新所有跟踪中的订单, 直到 ctx 取消; 订单结束后不再更新, 轨迹保留至 Untrack
+func (t *Tracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval())
+	defer ticker.Stop()
+
+	for {
+		for _, id := range t.Orders() {
+			t.Update(id)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update	查询订单及司机位置, 追加轨迹点并推送快照
+func (t *Tracker) Update(orderID string) (*Snapshot, error) {
+	t.mu.RLock()
+	_, ok := t.trails[orderID]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotTracked
+	}
+
+	od, err := t.Client.GetOrderDetail(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var d *driver.DriverDetail
+	if od.DriverId != "" && isActive(od.Status) {
+		if d, err = t.Client.GetDriverDetail(orderID, od.DriverId); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tr, ok := t.trails[orderID]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+
+	snap := t.apply(tr, od, d)
+	for ch := range t.subs[orderID] {
+		// 订阅方处理不及时则丢弃本次快照
+		select {
+		case ch <- snap:
+		default:
+		}
+	}
+	if isFinished(od.Status) {
+		tr.finished = true
+		t.closeSubs(orderID)
+	}
+	return &snap, nil
+}
+
+// apply	更新轨迹并计算快照 (调用方需持有写锁)
+func (t *Tracker) apply(tr *trail, od *order.OrderDetail, d *driver.DriverDetail) Snapshot {
+	if len(od.Stops) > 0 {
+		tr.stops = od.Stops
+	}
+
+	snap := Snapshot{
+		OrderID: od.ID,
+		DriverID: od.DriverId,
+		Status: od.Status,
+		NextStop: -1,
+	}
+	if d == nil {
+		if tr.last != nil {
+			snap.Position = tr.last.Position
+		}
+		tr.last = &snap
+		return snap
+	}
+
+	crumb := Breadcrumb{Coordinates: d.Coordinates, Time: t.now()}
+	if n := len(tr.crumbs); n > 0 {
+		prev := tr.crumbs[n-1]
+		if dist, err := prev.Coordinates.DistanceTo(crumb.Coordinates); err == nil {
+			if secs := crumb.Time.Sub(prev.Time).Seconds(); secs > 0 {
+				snap.Speed = dist / secs
+			}
+		}
+	}
+	tr.crumbs = append(tr.crumbs, crumb)
+	if max := t.maxCrumbs(); len(tr.crumbs) > max {
+		tr.crumbs = tr.crumbs[len(tr.crumbs)-max:]
+	}
+	snap.Position = crumb
+
+	// 标记已到达的站点; 取货前只判断取货点
+	for i, stop := range tr.stops {
+		if i > 0 && od.Status == enum.ORDER_STATUS_GOING {
+			break
+		}
+		if dist, err := crumb.Coordinates.DistanceTo(stop.Coordinates); err == nil && dist <= t.arrivalRadius() {
+			tr.reached[i] = true
+		}
+	}
+	if od.Status == enum.ORDER_STATUS_PICKUP {
+		tr.reached[0] = true
+	}
+
+	for i, stop := range tr.stops {
+		if tr.reached[i] {
+			continue
+		}
+		snap.NextStop = i
+		snap.DistanceRemaining, _ = crumb.Coordinates.DistanceTo(stop.Coordinates)
+		break
+	}
+
+	tr.last = &snap
+	return snap
+}
+
+// Trail	返回订单轨迹点
+func (t *Tracker) Trail(orderID string) ([]Breadcrumb, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tr, ok := t.trails[orderID]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+	crumbs := make([]Breadcrumb, len(tr.crumbs))
+	copy(crumbs, tr.crumbs)
+	return crumbs, nil
+}
+
+// Last	返回订单最近一次快照
+func (t *Tracker) Last(orderID string) (*Snapshot, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tr, ok := t.trails[orderID]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+	if tr.last == nil {
+		return nil, nil
+	}
+	snap := *tr.last
+	return &snap, nil
+}
+
+// Subscribe	订阅订单快照; 返回的 cancel 用于取消订阅. 订单结束或停止跟踪后通道关闭
+func (t *Tracker) Subscribe(orderID string) (<-chan Snapshot, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tr, ok := t.trails[orderID]
+	if !ok {
+		return nil, nil, ErrNotTracked
+	}
+	ch := make(chan Snapshot, 8)
+	if t.subs[orderID] == nil {
+		t.subs[orderID] = make(map[chan Snapshot]struct{})
+	}
+	t.subs[orderID][ch] = struct{}{}
+	if tr.finished {
+		t.closeSubs(orderID)
+	}
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.subs[orderID][ch]; ok {
+			delete(t.subs[orderID], ch)
+			close(ch)
+		}
+	}
+	return ch, cancel, nil
+}
+
+func (t *Tracker) now() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+	return time.Now()
+}
+
+func (t *Tracker) interval() time.Duration {
+	if t.Interval > 0 {
+		return t.Interval
+	}
+	return 10 * time.Second
+}
+
+func (t *Tracker) arrivalRadius() float64 {
+	if t.ArrivalRadius > 0 {
+		return t.ArrivalRadius
+	}
+	return 100
+}
+
+func (t *Tracker) maxCrumbs() int {
+	if t.MaxCrumbs > 0 {
+		return t.MaxCrumbs
+	}
+	return 1000
+}
+
+// isActive	司机是否在配送中
+func isActive(status string) bool {
+	return status == enum.ORDER_STATUS_GOING || status == enum.ORDER_STATUS_PICKUP
+}
+
+// isFinished	订单是否已结束
+func isFinished(status string) bool {
+	switch status {
+	case enum.ORDER_STATUS_COMPLETED, enum.ORDER_STATUS_CANCELED, enum.ORDER_STATUS_REJECTED, enum.ORDER_STATUS_EXPIRED:
+		return true
+	}
+	return false
+}
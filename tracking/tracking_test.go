@@ -0,0 +1,173 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+var stops = []quotation.DeliveryStop{
+	{ID: "S0", Address: "Pickup", Coordinates: quotation.Coordinates{Lat: "22.3000", Lng: "114.1700"}},
+	{ID: "S1", Address: "Dropoff", Coordinates: quotation.Coordinates{Lat: "22.3200", Lng: "114.1700"}},
+}
+
+// fakeClient	按步骤返回订单状态及司机位置
+type fakeClient struct {
+	mu sync.Mutex
+	step int
+	statuses []string
+	positions []quotation.Coordinates
+}
+
+func (f *fakeClient) GetOrderDetail(orderID string) (*order.OrderDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &order.OrderDetail{ID: orderID, DriverId: "D1", Status: f.statuses[f.step], Stops: stops}, nil
+}
+
+func (f *fakeClient) GetDriverDetail(orderID, driverID string) (*driver.DriverDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.positions[f.step]
+	f.step++
+	return &driver.DriverDetail{ID: driverID, Coordinates: c}, nil
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		statuses: []string{enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_PICKUP, enum.ORDER_STATUS_COMPLETED},
+		positions: []quotation.Coordinates{
+			{Lat: "22.2910", Lng: "114.1700"},
+			{Lat: "22.3000", Lng: "114.1700"},
+			{Lat: "22.3100", Lng: "114.1700"},
+		},
+	}
+}
+
+func newTracker(client Client) *Tracker {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	tracker := NewTracker(client)
+	tracker.Now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return tracker
+}
+
+func TestTrackerUpdate(t *testing.T) {
+	tracker := newTracker(newFakeClient())
+	tracker.Track("O1")
+
+	snap, err := tracker.Update("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, snap.NextStop)
+	assert.InDelta(t, 1000, snap.DistanceRemaining, 10)
+	assert.Zero(t, snap.Speed)
+
+	// 到达取货点, 约1公里/分钟
+	snap, _ = tracker.Update("O1")
+	assert.Equal(t, 1, snap.NextStop)
+	assert.InDelta(t, 1000.0/60, snap.Speed, 0.5)
+	assert.InDelta(t, 2224, snap.DistanceRemaining, 10)
+
+	snap, _ = tracker.Update("O1")
+	assert.Equal(t, 1, snap.NextStop)
+	assert.InDelta(t, 1112, snap.DistanceRemaining, 10)
+
+	// 订单完成后不再更新
+	snap, _ = tracker.Update("O1")
+	assert.Equal(t, enum.ORDER_STATUS_COMPLETED, snap.Status)
+	assert.Empty(t, tracker.Orders())
+
+	crumbs, _ := tracker.Trail("O1")
+	assert.Len(t, crumbs, 3)
+
+	_, err = tracker.Update("O2")
+	assert.ErrorIs(t, err, ErrNotTracked)
+}
+
+func TestGeoJSON(t *testing.T) {
+	tracker := newTracker(newFakeClient())
+	tracker.Track("O1")
+	tracker.Update("O1")
+	tracker.Update("O1")
+
+	fc, err := tracker.GeoJSON("O1")
+	assert.NoError(t, err)
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	if assert.Len(t, fc.Features, 3) {
+		assert.Equal(t, "LineString", fc.Features[0].Geometry.Type)
+		assert.Equal(t, [][]float64{{114.17, 22.291}, {114.17, 22.3}}, fc.Features[0].Geometry.Coordinates)
+		assert.Equal(t, "Point", fc.Features[1].Geometry.Type)
+		assert.Equal(t, true, fc.Features[1].Properties["reached"])
+		assert.Equal(t, false, fc.Features[2].Properties["reached"])
+	}
+
+	// 只有一个位置时输出 Point; 没有位置时省略轨迹
+	single := newTracker(newFakeClient())
+	single.Track("O1")
+	fc, _ = single.GeoJSON("O1")
+	assert.Empty(t, fc.Features)
+	single.Update("O1")
+	fc, _ = single.GeoJSON("O1")
+	if assert.Len(t, fc.Features, 3) {
+		assert.Equal(t, "Point", fc.Features[0].Geometry.Type)
+		assert.Equal(t, []float64{114.17, 22.291}, fc.Features[0].Geometry.Coordinates)
+		assert.Equal(t, "O1", fc.Features[0].Properties["orderId"])
+	}
+
+	srv := httptest.NewServer(tracker.GeoJSONHandler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "?orderId=O1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	decoded := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	assert.Equal(t, "FeatureCollection", decoded["type"])
+}
+
+func TestSSEHandler(t *testing.T) {
+	tracker := newTracker(newFakeClient())
+	tracker.Track("O1")
+	tracker.Update("O1")
+
+	srv := httptest.NewServer(tracker.SSEHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?orderId=O1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			tracker.Update("O1")
+		}
+	}()
+
+	events := make([]string, 0)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		}
+	}
+	assert.Equal(t, "position", events[0])
+	assert.Equal(t, "end", events[len(events)-1])
+
+	resp, _ = http.Get(srv.URL + "?orderId=O2")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+// ErrInvalidPhone	手机号码格式错误
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// phoneRule	各市场手机号码规则
+type phoneRule struct {
+	// 国家代码
+	code string
+	// 本地长途前缀; 为空表示无
+	trunk string
+	// 不含国家代码及长途前缀的号码
+	pattern *regexp.Regexp
+}
+
+// 各市场手机号码规则
+var phoneRules = map[string]phoneRule{
+	// 区号 2 位 + 9 开头的 9 位手机号
+	enum.AREA_CODE_BR: {code: "55", trunk: "0", pattern: regexp.MustCompile(`^[1-9]{2}9\d{8}$`)},
+	// 香港固话与手机号码同为 8 位, 均可作为联系电话
+	enum.AREA_CODE_HK: {code: "852", pattern: regexp.MustCompile(`^[1-9]\d{7}$`)},
+	enum.AREA_CODE_ID: {code: "62", trunk: "0", pattern: regexp.MustCompile(`^8\d{8,11}$`)},
+	enum.AREA_CODE_MY: {code: "60", trunk: "0", pattern: regexp.MustCompile(`^1\d{8,9}$`)},
+	enum.AREA_CODE_MX: {code: "52", pattern: regexp.MustCompile(`^[1-9]\d{9}$`)},
+	enum.AREA_CODE_PH: {code: "63", trunk: "0", pattern: regexp.MustCompile(`^9\d{9}$`)},
+	enum.AREA_CODE_SG: {code: "65", pattern: regexp.MustCompile(`^[3689]\d{7}$`)},
+	enum.AREA_CODE_TW: {code: "886", trunk: "0", pattern: regexp.MustCompile(`^9\d{8}$`)},
+	enum.AREA_CODE_TH: {code: "66", trunk: "0", pattern: regexp.MustCompile(`^[689]\d{8}$`)},
+	enum.AREA_CODE_VN: {code: "84", trunk: "0", pattern: regexp.MustCompile(`^[35789]\d{8}$`)},
+}
+
+// national	去除国家代码及本地长途前缀后的号码; 不符合规则时返回空
+func (r phoneRule) national(digits string, international bool) string {
+	candidates := make([]string, 0, 4)
+	if !international {
+		candidates = append(candidates, digits)
+		if r.trunk != "" && strings.HasPrefix(digits, r.trunk) {
+			candidates = append(candidates, strings.TrimPrefix(digits, r.trunk))
+		}
+	}
+	if rest := strings.TrimPrefix(digits, r.code); rest != digits {
+		candidates = append(candidates, rest)
+		// 如 +852 (0) 形式或墨西哥旧式的 +52 1
+		if r.trunk != "" {
+			candidates = append(candidates, strings.TrimPrefix(rest, r.trunk))
+		}
+		if r.code == "52" {
+			candidates = append(candidates, strings.TrimPrefix(rest, "1"))
+		}
+	}
+	for _, number := range candidates {
+		if r.pattern.MatchString(number) {
+			return number
+		}
+	}
+	return ""
+}
+
+// NormalizePhone	将本地格式的号码按市场 (enum.AREA_CODE_*) 转换为 E.164 格式并校验号码长度,
+// 如香港 8 位号码 "6123 4567" 转换为 "+85261234567". 带其他国家代码的号码按其所属市场规则校验;
+// 未知市场或国家代码时只校验 E.164 格式
+func NormalizePhone(phone, market string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	international := false
+	digits := cleaned
+	switch {
+	case strings.HasPrefix(cleaned, "+"):
+		international = true
+		digits = cleaned[1:]
+	case strings.HasPrefix(cleaned, "00"):
+		international = true
+		digits = cleaned[2:]
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+		}
+	}
+
+	rule, ok := phoneRules[strings.ToUpper(market)]
+	if international && (!ok || !strings.HasPrefix(digits, rule.code)) {
+		// 其他国家/地区的号码按其所属市场规则校验
+		for other, r := range phoneRules {
+			if strings.HasPrefix(digits, r.code) {
+				rule, market, ok = r, other, true
+				break
+			}
+		}
+		if !ok || !strings.HasPrefix(digits, rule.code) {
+			if CheckPhone("+" + digits) {
+				return "+" + digits, nil
+			}
+			return "", fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+	}
+
+	number := rule.national(digits, international)
+	if number == "" {
+		return "", fmt.Errorf("%w: %q is not a valid %s number", ErrInvalidPhone, phone, strings.ToUpper(market))
+	}
+	return "+" + rule.code + number, nil
+}
+
+// CheckMarketPhone	校验号码是否为指定市场的有效号码
+func CheckMarketPhone(phone, market string) bool {
+	_, err := NormalizePhone(phone, market)
+	return err == nil
+}
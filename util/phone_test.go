@@ -0,0 +1,85 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := []struct {
+		market string
+		phone string
+		want string
+	}{
+		// Brasil: 区号 + 9 开头的 9 位手机号
+		{enum.AREA_CODE_BR, "(11) 91234-5678", "+5511912345678"},
+		{enum.AREA_CODE_BR, "011 91234 5678", "+5511912345678"},
+		{enum.AREA_CODE_BR, "+55 11 91234-5678", "+5511912345678"},
+		{enum.AREA_CODE_BR, "11 1234-5678", ""},
+		// Hong Kong: 8 位
+		{enum.AREA_CODE_HK, "6123 4567", "+85261234567"},
+		{enum.AREA_CODE_HK, "38485765", "+85238485765"},
+		{enum.AREA_CODE_HK, "+852 6123-4567", "+85261234567"},
+		{enum.AREA_CODE_HK, "852 6123 4567", "+85261234567"},
+		{enum.AREA_CODE_HK, "00852 61234567", "+85261234567"},
+		{enum.AREA_CODE_HK, "85212345", "+85285212345"},
+		{enum.AREA_CODE_HK, "1234567", ""},
+		{enum.AREA_CODE_HK, "612345678", ""},
+		{enum.AREA_CODE_HK, "+65 1234 5678", ""},
+		// Indonesia: 8 开头 9-12 位
+		{enum.AREA_CODE_ID, "0812-3456-7890", "+6281234567890"},
+		{enum.AREA_CODE_ID, "+62 812 3456 789", "+628123456789"},
+		{enum.AREA_CODE_ID, "0212345678", ""},
+		// Malaysia: 1 开头 9-10 位
+		{enum.AREA_CODE_MY, "012-345 6789", "+60123456789"},
+		{enum.AREA_CODE_MY, "011-1234 5678", "+601112345678"},
+		{enum.AREA_CODE_MY, "03-1234 5678", ""},
+		// Mexico: 10 位
+		{enum.AREA_CODE_MX, "55 1234 5678", "+525512345678"},
+		{enum.AREA_CODE_MX, "+52 1 55 1234 5678", "+525512345678"},
+		{enum.AREA_CODE_MX, "5512345", ""},
+		// Philippines: 9 开头 10 位
+		{enum.AREA_CODE_PH, "0917 123 4567", "+639171234567"},
+		{enum.AREA_CODE_PH, "+63 917 123 4567", "+639171234567"},
+		{enum.AREA_CODE_PH, "02 1234 5678", ""},
+		// Singapore: 8 位
+		{enum.AREA_CODE_SG, "9123 4567", "+6591234567"},
+		{enum.AREA_CODE_SG, "+65 8123 4567", "+6581234567"},
+		{enum.AREA_CODE_SG, "1234 5678", ""},
+		// Taiwan: 9 开头 9 位
+		{enum.AREA_CODE_TW, "0912-345-678", "+886912345678"},
+		{enum.AREA_CODE_TW, "+886 912 345 678", "+886912345678"},
+		{enum.AREA_CODE_TW, "02-2345-6789", ""},
+		{enum.AREA_CODE_TW, "+852 3848 5765", "+85238485765"},
+		// Thailand: 6/8/9 开头 9 位
+		{enum.AREA_CODE_TH, "081-234-5678", "+66812345678"},
+		{enum.AREA_CODE_TH, "+66 61 234 5678", "+66612345678"},
+		{enum.AREA_CODE_TH, "02-123-4567", ""},
+		// Vietnam: 3/5/7/8/9 开头 9 位
+		{enum.AREA_CODE_VN, "091 234 5678", "+84912345678"},
+		{enum.AREA_CODE_VN, "+84 38 123 4567", "+84381234567"},
+		{enum.AREA_CODE_VN, "024 1234 5678", ""},
+		// 未知市场只校验 E.164 格式
+		{"", "+44 20 7946 0958", "+442079460958"},
+		{"", "020 7946 0958", ""},
+		{enum.AREA_CODE_SG, "+44 20 7946 0958", "+442079460958"},
+		// 非法字符
+		{enum.AREA_CODE_HK, "6123abcd", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.market+"/"+c.phone, func(t *testing.T) {
+			got, err := NormalizePhone(c.phone, c.market)
+			if c.want == "" {
+				assert.ErrorIs(t, err, ErrInvalidPhone)
+				assert.False(t, CheckMarketPhone(c.phone, c.market))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, got)
+			assert.True(t, CheckPhone(got))
+		})
+	}
+}
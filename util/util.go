@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"io"
+	"math"
 	"regexp"
 )
 
@@ -39,3 +40,18 @@ func CheckPhone(phone string) bool {
 	}
 	return false
 }
+
+
+// 地球平均半径 (米)
+const earthRadius = 6371000.0
+
+// Haversine	计算两个经纬度坐标之间的球面距离 (米)
+func Haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -16,4 +16,12 @@ func TestCheckPhone(t *testing.T) {
 	phone := "+6512345678"
 	t.Logf("\n----> check phone number: \"%s\"\n", phone)
 	assert.True(t, CheckPhone(phone))
+}
+
+func TestHaversine(t *testing.T) {
+	// Innocentre -> Canton Rd, 约 4.5 公里
+	d := Haversine(22.33547351186244, 114.17615807116502, 22.29553167157697, 114.16885175766998)
+	t.Logf("\n----> distance: %f\n", d)
+	assert.InDelta(t, 4500, d, 100)
+	assert.Zero(t, Haversine(22.3, 114.1, 22.3, 114.1))
 }
\ No newline at end of file
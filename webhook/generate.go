@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/util"
+)
+
+// Generator	模拟 Lalamove 生成已签名的 webhook 事件; 用于测试事件处理函数
+type Generator struct {
+	Apikey string
+	Secret string
+	// 签名使用的请求路径; 默认 "/"
+	Path string
+	// 可选; 事件时间 (用于测试)
+	Now func() time.Time
+	// 可选; Send 每推送成功一个事件时回调
+	OnSent func(e Event)
+}
+
+// LifecycleOptions	订单生命周期模拟配置
+type LifecycleOptions struct {
+	// 第一个事件的时间; 默认当前时间
+	Start time.Time
+	// 相邻两个事件的时间间隔; 默认 1 分钟
+	Step time.Duration
+	// 订单最终状态; 默认 COMPLETED. 为 ASSIGNING_DRIVER/CANCELED/REJECTED/EXPIRED 时不分配司机
+	Final string
+}
+
+// now	当前时间
+func (g *Generator) now() time.Time {
+	if g.Now != nil {
+		return g.Now()
+	}
+	return time.Now()
+}
+
+// event	构造并签名事件
+func (g *Generator) event(eventType string, at time.Time, data Data) Event {
+	data.UpdatedAt = at.UTC().Format("2006-01-02T15:04:05.00Z")
+	raw, _ := json.Marshal(data)
+	e := Event{
+		Apikey: g.Apikey,
+		Timestamp: at.Unix(),
+		EventID: util.UniqueID(),
+		EventType: eventType,
+		EventVersion: "v3",
+		Data: raw,
+	}
+	path := g.Path
+	if path == "" {
+		path = "/"
+	}
+	Sign(g.Secret, path, &e)
+	return e
+}
+
+// orderOf	事件中的订单信息
+func orderOf(od order.OrderDetail, previous string) *Order {
+	return &Order{OrderDetail: od, PreviousStatus: previous}
+}
+
+// OrderStatusChanged	订单状态变更事件; od.Status 为新状态
+func (g *Generator) OrderStatusChanged(od order.OrderDetail, previous string) Event {
+	return g.event(EVENT_ORDER_STATUS_CHANGED, g.now(), Data{Order: orderOf(od, previous)})
+}
+
+// DriverAssigned	司机接单事件
+func (g *Generator) DriverAssigned(od order.OrderDetail, d driver.DriverDetail) Event {
+	od.DriverId = d.ID
+	location := d.Coordinates
+	return g.event(EVENT_DRIVER_ASSIGNED, g.now(), Data{Order: orderOf(od, ""), Driver: &d, Location: &location})
+}
+
+// OrderAmountChanged	订单金额变更事件 (如添加小费)
+func (g *Generator) OrderAmountChanged(od order.OrderDetail) Event {
+	return g.event(EVENT_ORDER_AMOUNT_CHANGED, g.now(), Data{Order: orderOf(od, "")})
+}
+
+// OrderReplaced	订单被替换事件
+func (g *Generator) OrderReplaced(od order.OrderDetail, prevOrderID string) Event {
+	return g.event(EVENT_ORDER_REPLACED, g.now(), Data{Order: orderOf(od, ""), PrevOrderID: prevOrderID})
+}
+
+// OrderEdited	订单编辑事件
+func (g *Generator) OrderEdited(od order.OrderDetail) Event {
+	return g.event(EVENT_ORDER_EDITED, g.now(), Data{Order: orderOf(od, "")})
+}
+
+// WalletBalanceChanged	钱包余额变更事件
+func (g *Generator) WalletBalanceChanged(currency, amount string) Event {
+	return g.event(EVENT_WALLET_BALANCE_CHANGED, g.now(), Data{Balance: &Balance{Currency: currency, Amount: amount}})
+}
+
+// Lifecycle	模拟完整订单生命周期的事件序列:
+// ASSIGNING_DRIVER → DRIVER_ASSIGNED → ON_GOING → PICKED_UP → COMPLETED, 或以 CANCELED/REJECTED/EXPIRED 提前结束
+func (g *Generator) Lifecycle(od order.OrderDetail, d driver.DriverDetail, opts LifecycleOptions) []Event {
+	at := opts.Start
+	if at.IsZero() {
+		at = g.now()
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = time.Minute
+	}
+	final := opts.Final
+	if final == "" {
+		final = enum.ORDER_STATUS_COMPLETED
+	}
+
+	events := make([]Event, 0)
+	previous := ""
+	status := func(next string) {
+		od.Status = next
+		events = append(events, g.event(EVENT_ORDER_STATUS_CHANGED, at, Data{Order: orderOf(od, previous)}))
+		previous = next
+		at = at.Add(step)
+	}
+
+	od.DriverId = ""
+	status(enum.ORDER_STATUS_ASSIGN)
+	if final == enum.ORDER_STATUS_ASSIGN {
+		return events
+	}
+	if final != enum.ORDER_STATUS_COMPLETED && final != enum.ORDER_STATUS_PICKUP && final != enum.ORDER_STATUS_GOING {
+		status(final)
+		return events
+	}
+
+	od.DriverId = d.ID
+	location := d.Coordinates
+	events = append(events, g.event(EVENT_DRIVER_ASSIGNED, at, Data{Order: orderOf(od, ""), Driver: &d, Location: &location}))
+	at = at.Add(step)
+
+	for _, next := range []string{enum.ORDER_STATUS_GOING, enum.ORDER_STATUS_PICKUP, enum.ORDER_STATUS_COMPLETED} {
+		status(next)
+		if next == final {
+			break
+		}
+	}
+	return events
+}
+
+// Request	构造推送到 target 的已签名请求 (按 target 的路径重新签名); 可直接交给 http.Handler 测试
+func (g *Generator) Request(target string, e Event) (*http.Request, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	Sign(g.Secret, u.Path, &e)
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Send	按事件时间间隔 (乘以 speed, 为 0 时不等待) 依次推送事件到 target
+func (g *Generator) Send(ctx context.Context, client *http.Client, target string, events []Event, speed float64) error {
+	for i, e := range events {
+		if i > 0 && speed > 0 {
+			wait := time.Duration(float64(e.Time().Sub(events[i-1].Time())) * speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		if err := Deliver(ctx, client, target, g.Secret, e); err != nil {
+			return err
+		}
+		if g.OnSent != nil {
+			g.OnSent(e)
+		}
+	}
+	return nil
+}
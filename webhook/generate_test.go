@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+)
+
+var (
+	testOrder = order.OrderDetail{ID: "107900701184", QuotationId: "Q1"}
+	testDriver = driver.DriverDetail{
+		ID: "80557",
+		PlateNo: "VP9946964",
+		Coordinates: quotation.Coordinates{Lat: "22.3354", Lng: "114.1761"},
+		Driver: driver.Driver{Name: "David", Phone: "+85212345678"},
+	}
+)
+
+func TestGeneratorLifecycle(t *testing.T) {
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	gen := &Generator{Apikey: "pk_test", Secret: testSecret}
+	events := gen.Lifecycle(testOrder, testDriver, LifecycleOptions{Start: start, Step: 5 * time.Minute})
+
+	types := make([]string, 0)
+	statuses := make([]string, 0)
+	for i, e := range events {
+		types = append(types, e.EventType)
+		assert.Equal(t, start.Add(time.Duration(i)*5*time.Minute).Unix(), e.Timestamp)
+		assert.NoError(t, Verify(testSecret, "/", e))
+		data, err := e.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, testOrder.ID, e.OrderID())
+		if e.EventType == EVENT_ORDER_STATUS_CHANGED {
+			statuses = append(statuses, data.Order.PreviousStatus+">"+data.Order.Status)
+		}
+		if e.EventType == EVENT_DRIVER_ASSIGNED {
+			assert.Equal(t, testDriver.ID, data.Driver.ID)
+			assert.Equal(t, testDriver.ID, data.Order.DriverId)
+			assert.Equal(t, "22.3354", data.Location.Lat)
+		}
+	}
+	assert.Equal(t, []string{
+		EVENT_ORDER_STATUS_CHANGED, EVENT_DRIVER_ASSIGNED, EVENT_ORDER_STATUS_CHANGED,
+		EVENT_ORDER_STATUS_CHANGED, EVENT_ORDER_STATUS_CHANGED,
+	}, types)
+	assert.Equal(t, []string{">ASSIGNING_DRIVER", "ASSIGNING_DRIVER>ON_GOING", "ON_GOING>PICKED_UP", "PICKED_UP>COMPLETED"}, statuses)
+
+	// 提前结束的订单不分配司机
+	events = gen.Lifecycle(testOrder, testDriver, LifecycleOptions{Start: start, Final: enum.ORDER_STATUS_EXPIRED})
+	assert.Len(t, events, 2)
+	data, _ := events[1].Decode()
+	assert.Equal(t, enum.ORDER_STATUS_EXPIRED, data.Order.Status)
+	assert.Nil(t, data.Driver)
+
+	// 停留在待接单
+	events = gen.Lifecycle(testOrder, testDriver, LifecycleOptions{Start: start, Final: enum.ORDER_STATUS_ASSIGN})
+	if assert.Len(t, events, 1) {
+		data, _ = events[0].Decode()
+		assert.Equal(t, enum.ORDER_STATUS_ASSIGN, data.Order.Status)
+	}
+
+	// 生成的事件可由处理器按顺序处理
+	handled := 0
+	p := &Processor{Seen: NewMemorySeenStore(0), Handler: func(e Event) error { handled++; return nil }}
+	for _, e := range gen.Lifecycle(testOrder, testDriver, LifecycleOptions{Start: start}) {
+		assert.NoError(t, p.Handle(e))
+	}
+	assert.Equal(t, 5, handled)
+}
+
+func TestGeneratorEvents(t *testing.T) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	gen := &Generator{Apikey: "pk_test", Secret: testSecret, Path: testPath, Now: func() time.Time { return now }}
+
+	od := testOrder
+	od.Status = enum.ORDER_STATUS_PICKUP
+	events := []Event{
+		gen.OrderStatusChanged(od, enum.ORDER_STATUS_GOING),
+		gen.DriverAssigned(od, testDriver),
+		gen.OrderAmountChanged(od),
+		gen.OrderReplaced(od, "107900701183"),
+		gen.OrderEdited(od),
+		gen.WalletBalanceChanged("HKD", "1000"),
+	}
+	for _, e := range events {
+		assert.NoError(t, Verify(testSecret, testPath, e))
+		assert.True(t, now.Equal(e.Time()))
+		_, err := e.Decode()
+		assert.NoError(t, err)
+	}
+	data, _ := events[3].Decode()
+	assert.Equal(t, "107900701183", data.PrevOrderID)
+	data, _ = events[5].Decode()
+	assert.Equal(t, "1000", data.Balance.Amount)
+}
+
+func TestGeneratorRequest(t *testing.T) {
+	now := time.Now()
+	received := make([]string, 0)
+	r := &Receiver{
+		Secret: testSecret,
+		Apikey: "pk_test",
+		Tolerance: time.Minute,
+		Handler: func(e Event) error {
+			received = append(received, e.EventType)
+			return nil
+		},
+	}
+	gen := &Generator{Apikey: "pk_test", Secret: testSecret, Now: func() time.Time { return now }}
+
+	// 直接交给 http.Handler
+	req, err := gen.Request("http://localhost"+testPath, gen.OrderEdited(testOrder))
+	assert.NoError(t, err)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 推送到本地地址
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	events := gen.Lifecycle(testOrder, testDriver, LifecycleOptions{Start: now, Step: time.Millisecond})
+	sent := 0
+	gen.OnSent = func(e Event) { sent++ }
+	assert.NoError(t, gen.Send(context.Background(), nil, srv.URL+"/hook", events, 1))
+	assert.Equal(t, len(events), sent)
+	assert.Equal(t, []string{
+		EVENT_ORDER_EDITED, EVENT_ORDER_STATUS_CHANGED, EVENT_DRIVER_ASSIGNED,
+		EVENT_ORDER_STATUS_CHANGED, EVENT_ORDER_STATUS_CHANGED, EVENT_ORDER_STATUS_CHANGED,
+	}, received)
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/util"
+)
+
+// 预检事件类型 (仅用于本地预检, Lalamove 不会推送)
+const EVENT_TEST = "WEBHOOK_TEST"
+
+// Registrar	webhook 地址设置接口 (*lalamove.Client 已实现)
+type Registrar interface {
+	GetWebhook() (string, error)
+	SetWebhook(url string) (bool, error)
+}
+
+// CheckResult	预检结果
+type CheckResult struct {
+	URL string `json:"url"`
+	// 签名正确的预检事件响应状态码
+	StatusCode int `json:"statusCode"`
+	Latency time.Duration `json:"latency"`
+	// 目标地址是否拒绝签名错误的事件
+	VerifiesSignature bool `json:"verifiesSignature"`
+}
+
+// SwitchResult	切换结果
+type SwitchResult struct {
+	Previous string `json:"previous"`
+	Current string `json:"current"`
+	Check *CheckResult `json:"check,omitempty"`
+	// 设置失败后是否已恢复为原地址
+	RolledBack bool `json:"rolledBack"`
+}
+
+// Manager	webhook 地址管理; 设置前预检目标地址, 设置失败时恢复原地址
+type Manager struct {
+	Client Registrar
+	Apikey string
+	Secret string
+	// 可选; 预检使用的HTTP客户端, 默认超时 10 秒
+	HTTPClient *http.Client
+	// 可选; 要求目标地址拒绝签名错误的事件
+	RequireSignatureCheck bool
+}
+
+// Current	查询当前地址
+func (m *Manager) Current() (string, error) {
+	return m.Client.GetWebhook()
+}
+
+// Check	向目标地址推送使用 secret 签名的预检事件; 响应非 2xx 时返回错误
+func (m *Manager) Check(ctx context.Context, target string) (*CheckResult, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook: invalid url %q", target)
+	}
+
+	data, _ := json.Marshal(map[string]string{"updatedAt": time.Now().UTC().Format(time.RFC3339)})
+	e := Event{
+		Apikey: m.Apikey,
+		Timestamp: time.Now().Unix(),
+		EventID: "TEST-" + util.UniqueID(),
+		EventType: EVENT_TEST,
+		EventVersion: "v3",
+		Data: data,
+	}
+	result := &CheckResult{URL: target}
+
+	Sign(m.Secret, u.Path, &e)
+	start := time.Now()
+	result.StatusCode, err = m.post(ctx, target, e)
+	result.Latency = time.Since(start)
+	if err != nil {
+		return result, err
+	}
+	if result.StatusCode < http.StatusOK || result.StatusCode >= http.StatusMultipleChoices {
+		return result, fmt.Errorf("webhook: %s responded %d to a signed test event", target, result.StatusCode)
+	}
+
+	// 签名错误的事件应被拒绝
+	e.Signature = util.Signature("invalid", e.Signature)
+	status, err := m.post(ctx, target, e)
+	if err != nil {
+		return result, err
+	}
+	result.VerifiesSignature = status >= http.StatusBadRequest
+	if m.RequireSignatureCheck && !result.VerifiesSignature {
+		return result, fmt.Errorf("webhook: %s accepted an event with an invalid signature", target)
+	}
+	return result, nil
+}
+
+// post	推送事件并返回响应状态码
+func (m *Manager) post(ctx context.Context, target string, e Event) (int, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := m.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// Switch	预检通过后设置新地址; 设置失败或查询结果不一致时恢复原地址.
+// 无法查询原地址时 (接口不支持) 不进行恢复
+func (m *Manager) Switch(ctx context.Context, target string) (*SwitchResult, error) {
+	result := &SwitchResult{}
+
+	check, err := m.Check(ctx, target)
+	result.Check = check
+	if err != nil {
+		return result, err
+	}
+
+	previous, err := m.Client.GetWebhook()
+	if err != nil {
+		previous = ""
+	}
+	result.Previous = previous
+
+	ok, err := m.Client.SetWebhook(target)
+	if err == nil && !ok {
+		err = errors.New("webhook: set webhook returned false")
+	}
+	if err == nil {
+		// 设置后回读校验
+		current, getErr := m.Client.GetWebhook()
+		if getErr == nil && current != target {
+			err = fmt.Errorf("webhook: webhook is %q after setting %q", current, target)
+		}
+	}
+	if err == nil {
+		result.Current = target
+		return result, nil
+	}
+
+	result.Current = previous
+	if previous != "" && previous != target {
+		if ok, rollbackErr := m.Client.SetWebhook(previous); rollbackErr != nil || !ok {
+			return result, fmt.Errorf("%w (rollback to %q failed: %v)", err, previous, rollbackErr)
+		}
+		result.RolledBack = true
+	}
+	return result, err
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRegistrar	记录设置过的地址
+type fakeRegistrar struct {
+	url string
+	fail map[string]bool
+	sets []string
+}
+
+func (r *fakeRegistrar) GetWebhook() (string, error) {
+	return r.url, nil
+}
+
+func (r *fakeRegistrar) SetWebhook(url string) (bool, error) {
+	r.sets = append(r.sets, url)
+	if r.fail[url] {
+		return false, errors.New("[ERR_INVALID_URL] invalid url")
+	}
+	r.url = url
+	return true, nil
+}
+
+func TestManagerCheck(t *testing.T) {
+	verified := httptest.NewServer(&Receiver{Secret: testSecret})
+	defer verified.Close()
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer open.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	m := &Manager{Secret: testSecret}
+	check, err := m.Check(context.Background(), verified.URL+"/hook")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, check.StatusCode)
+	assert.True(t, check.VerifiesSignature)
+
+	check, err = m.Check(context.Background(), open.URL+"/hook")
+	assert.NoError(t, err)
+	assert.False(t, check.VerifiesSignature)
+	m.RequireSignatureCheck = true
+	_, err = m.Check(context.Background(), open.URL+"/hook")
+	assert.Error(t, err)
+
+	_, err = m.Check(context.Background(), broken.URL+"/hook")
+	assert.Error(t, err)
+	_, err = m.Check(context.Background(), "your.webhook.link")
+	assert.Error(t, err)
+
+	// secret 不一致时预检失败
+	_, err = (&Manager{Secret: "sk_other"}).Check(context.Background(), verified.URL+"/hook")
+	assert.Error(t, err)
+}
+
+func TestManagerSwitch(t *testing.T) {
+	srv := httptest.NewServer(&Receiver{Secret: testSecret})
+	defer srv.Close()
+
+	reg := &fakeRegistrar{url: "https://old.example.com/hook", fail: map[string]bool{}}
+	m := &Manager{Client: reg, Secret: testSecret}
+
+	result, err := m.Switch(context.Background(), srv.URL+"/hook")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://old.example.com/hook", result.Previous)
+	assert.Equal(t, srv.URL+"/hook", result.Current)
+	assert.False(t, result.RolledBack)
+
+	current, err := m.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, srv.URL+"/hook", current)
+
+	// 设置失败时恢复原地址
+	reg.fail[srv.URL+"/v2"] = true
+	result, err = m.Switch(context.Background(), srv.URL+"/v2")
+	assert.Error(t, err)
+	assert.True(t, result.RolledBack)
+	assert.Equal(t, srv.URL+"/hook", reg.url)
+	assert.Equal(t, []string{srv.URL + "/hook", srv.URL + "/v2", srv.URL + "/hook"}, reg.sets)
+
+	// 预检失败时不设置
+	reg.sets = nil
+	_, err = m.Switch(context.Background(), "http://127.0.0.1:1/hook")
+	assert.Error(t, err)
+	assert.Empty(t, reg.sets)
+}
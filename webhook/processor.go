@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/enum"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+)
+
+// 跳过原因
+const (
+	SKIP_DUPLICATE = "duplicate"
+	SKIP_STALE     = "stale"
+)
+
+// OrderLookup	订单最新状态查询 (store.OrderStore 已实现)
+type OrderLookup interface {
+	GetOrder(orderID string) (*order.OrderDetail, error)
+}
+
+// orderState	订单最近一次已处理的状态
+type orderState struct {
+	status string
+	at time.Time
+}
+
+// Processor	事件去重及状态排序; 包装 Handler, 保证每个事件最多被成功处理一次.
+// 订单状态事件早于已处理的状态事件、状态低于已处理的状态 (如接单后收到待接单), 或订单已结束时,
+// 视为过期事件并丢弃. Handler 在锁外调用, 可并发处理不同事件
+type Processor struct {
+	Handler Handler
+	// 可选; 为空时不去重
+	Seen SeenStore
+	// 可选; 订单首次出现时用于获取已知状态 (如重启后)
+	Orders OrderLookup
+	// 可选; 事件被跳过时回调
+	OnSkip func(e Event, reason string)
+
+	mu sync.Mutex
+	last map[string]orderState
+}
+
+// Handle	处理事件; 可作为 Receiver.Handler.
+// 重复及过期事件直接确认 (返回 nil), 处理失败时取消去重标记以便重新推送后再次处理
+func (p *Processor) Handle(e Event) error {
+	claimed := false
+	if p.Seen != nil && e.EventID != "" {
+		ok, err := p.Seen.Claim(e.EventID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			p.skip(e, SKIP_DUPLICATE)
+			return nil
+		}
+		claimed = true
+	}
+
+	orderID, next, isStatus := statusOf(e)
+	if isStatus {
+		p.mu.Lock()
+		stale := p.stale(orderID, next)
+		p.mu.Unlock()
+		if stale {
+			p.skip(e, SKIP_STALE)
+			return nil
+		}
+	}
+
+	if p.Handler != nil {
+		if err := p.Handler(e); err != nil {
+			if claimed {
+				p.Seen.Release(e.EventID)
+			}
+			return err
+		}
+	}
+
+	if isStatus {
+		p.mu.Lock()
+		// 处理期间可能已有更新的状态
+		if !p.stale(orderID, next) {
+			if p.last == nil {
+				p.last = make(map[string]orderState)
+			}
+			p.last[orderID] = next
+		}
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// stale	状态事件是否过期 (调用方需持有锁)
+func (p *Processor) stale(orderID string, next orderState) bool {
+	last, ok := p.last[orderID]
+	if !ok && p.Orders != nil {
+		if od, err := p.Orders.GetOrder(orderID); err == nil && od != nil {
+			last, ok = orderState{status: od.Status}, true
+		}
+	}
+	if !ok {
+		return false
+	}
+	if isFinal(last.status) {
+		return true
+	}
+	newer := !last.at.IsZero() && next.at.After(last.at)
+	if !last.at.IsZero() && next.at.Before(last.at) {
+		return true
+	}
+	// 同一时间的事件按状态先后判断; 状态相同视为重复
+	if !last.at.IsZero() && !newer && next.status == last.status {
+		return true
+	}
+	if lr, nr := statusRank(last.status), statusRank(next.status); lr > 0 && nr > 0 && nr < lr {
+		// 司机取消或被更换后订单回到待接单; 只有确认晚于已处理的状态时才接受
+		reassigned := last.status == enum.ORDER_STATUS_GOING && next.status == enum.ORDER_STATUS_ASSIGN && newer
+		return !reassigned
+	}
+	return false
+}
+
+// statusRank	订单状态先后顺序; 未知状态返回 0 (只按时间排序)
+func statusRank(status string) int {
+	switch status {
+	case enum.ORDER_STATUS_ASSIGN:
+		return 1
+	case enum.ORDER_STATUS_GOING:
+		return 2
+	case enum.ORDER_STATUS_PICKUP:
+		return 3
+	}
+	if isFinal(status) {
+		return 4
+	}
+	return 0
+}
+
+// skip	回调跳过事件
+func (p *Processor) skip(e Event, reason string) {
+	if p.OnSkip != nil {
+		p.OnSkip(e, reason)
+	}
+}
+
+// statusOf	返回状态事件的订单ID、状态及时间
+func statusOf(e Event) (string, orderState, bool) {
+	if e.EventType != EVENT_ORDER_STATUS_CHANGED {
+		return "", orderState{}, false
+	}
+	data, err := e.Decode()
+	if err != nil || data.Order == nil || data.Order.ID == "" {
+		return "", orderState{}, false
+	}
+
+	at := e.Time()
+	if updated, err := time.Parse(time.RFC3339, data.UpdatedAt); err == nil {
+		at = updated
+	}
+	return data.Order.ID, orderState{status: data.Order.Status, at: at}, true
+}
+
+// isFinal	订单是否已结束
+func isFinal(status string) bool {
+	switch status {
+	case enum.ORDER_STATUS_COMPLETED, enum.ORDER_STATUS_CANCELED, enum.ORDER_STATUS_REJECTED, enum.ORDER_STATUS_EXPIRED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/store"
+)
+
+func statusEvent(id, orderID, status, updatedAt string) Event {
+	return Event{
+		EventID: id,
+		EventType: EVENT_ORDER_STATUS_CHANGED,
+		Data: json.RawMessage(`{"order":{"orderId":"` + orderID + `","status":"` + status + `"},"updatedAt":"` + updatedAt + `"}`),
+	}
+}
+
+func TestProcessorDedupAndOrdering(t *testing.T) {
+	handled := make([]string, 0)
+	skipped := make([]string, 0)
+	p := &Processor{
+		Seen: NewMemorySeenStore(0),
+		Handler: func(e Event) error {
+			handled = append(handled, e.EventID)
+			return nil
+		},
+		OnSkip: func(e Event, reason string) { skipped = append(skipped, e.EventID+":"+reason) },
+	}
+
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E3", "O1", "PICKED_UP", "2023-06-01T10:20:00.00Z")))
+	// 迟到的旧状态
+	assert.NoError(t, p.Handle(statusEvent("E2", "O1", "ON_GOING", "2023-06-01T10:10:00.00Z")))
+	// 已取货后不会回到待接单
+	assert.NoError(t, p.Handle(statusEvent("E4", "O1", "ASSIGNING_DRIVER", "2023-06-01T10:30:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E5", "O1", "COMPLETED", "2023-06-01T11:00:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E6", "O1", "ON_GOING", "2023-06-01T11:05:00.00Z")))
+	// 非状态事件不参与排序
+	assert.NoError(t, p.Handle(Event{EventID: "E7", EventType: EVENT_WALLET_BALANCE_CHANGED}))
+
+	assert.Equal(t, []string{"E1", "E3", "E5", "E7"}, handled)
+	assert.Equal(t, []string{"E1:duplicate", "E2:stale", "E4:stale", "E6:stale"}, skipped)
+}
+
+func TestProcessorStatusRank(t *testing.T) {
+	orders := store.NewMemoryStore()
+	orders.Record(store.OrderEvent(store.EVENT_STATUS_SNAPSHOT, &order.OrderDetail{ID: "O2", Status: "PICKED_UP"}))
+
+	handled := make([]string, 0)
+	p := &Processor{
+		Orders: orders,
+		Handler: func(e Event) error {
+			handled = append(handled, e.EventID)
+			return nil
+		},
+	}
+
+	// 更换司机后回到待接单
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E2", "O1", "ASSIGNING_DRIVER", "2023-06-01T10:05:00.00Z")))
+	// 已知状态无时间时按状态先后判断
+	assert.NoError(t, p.Handle(statusEvent("E3", "O2", "ON_GOING", "2023-06-01T10:10:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E4", "O2", "COMPLETED", "2023-06-01T10:20:00.00Z")))
+
+	// 同一秒内的状态按先后判断
+	assert.NoError(t, p.Handle(statusEvent("E5", "O3", "ON_GOING", "2023-06-01T10:30:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E6", "O3", "PICKED_UP", "2023-06-01T10:30:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E7", "O3", "ON_GOING", "2023-06-01T10:30:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E8", "O3", "PICKED_UP", "2023-06-01T10:30:00.00Z")))
+
+	assert.Equal(t, []string{"E1", "E2", "E4", "E5", "E6"}, handled)
+}
+
+func TestProcessorHandlerUnlocked(t *testing.T) {
+	var p *Processor
+	done := make(chan error, 1)
+	p = &Processor{
+		Seen: NewMemorySeenStore(0),
+		Handler: func(e Event) error {
+			if e.EventID == "E1" {
+				// 处理中可处理其他事件
+				done <- p.Handle(statusEvent("E2", "O2", "ON_GOING", "2023-06-01T10:00:00.00Z"))
+			}
+			return nil
+		},
+	}
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	assert.NoError(t, <-done)
+}
+
+func TestSeenStoreTTL(t *testing.T) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	s := NewMemorySeenStore(time.Hour)
+	s.Now = func() time.Time { return now }
+
+	ok, _ := s.Claim("E1")
+	assert.True(t, ok)
+	ok, _ = s.Claim("E1")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	ok, _ = s.Claim("E2")
+	assert.True(t, ok)
+	assert.Equal(t, 1, s.Len())
+	// 过期后可再次处理
+	ok, _ = s.Claim("E1")
+	assert.True(t, ok)
+}
+
+func TestFileSeenStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	old := time.Now().Add(-2 * SEEN_TTL).UTC().Format(time.RFC3339)
+	content := `{"eventId":"E1","at":"` + old + `"}` + "\n" +
+		`{"eventId":"E2"}` + "\n" +
+		`{"eventId":"E3"}` + "\n" +
+		`{"eventId":"E3","released":true}` + "\n" +
+		`{"eventId":"E4","at`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	seen, err := OpenFileSeenStore(path, 0)
+	assert.NoError(t, err)
+	defer seen.Close()
+	assert.Equal(t, 1, seen.Len())
+
+	// 只保留未过期的标记; 不完整的记录被丢弃
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"eventId":"E2"`)
+	}
+
+	ok, err := seen.Claim("E1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 中间损坏的记录
+	assert.NoError(t, os.WriteFile(path, []byte(`{"eventId":`+"\n"+`{"eventId":"E2"}`+"\n"), 0644))
+	_, err = OpenFileSeenStore(path, 0)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestProcessorRelease(t *testing.T) {
+	fail := true
+	handled := 0
+	p := &Processor{
+		Seen: NewMemorySeenStore(0),
+		Handler: func(e Event) error {
+			if fail {
+				return errors.New("db down")
+			}
+			handled++
+			return nil
+		},
+	}
+
+	e := statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")
+	assert.Error(t, p.Handle(e))
+	// 重新推送后可再次处理
+	fail = false
+	assert.NoError(t, p.Handle(e))
+	assert.NoError(t, p.Handle(e))
+	assert.Equal(t, 1, handled)
+}
+
+func TestProcessorAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	orders := store.NewMemoryStore()
+	orders.Record(store.OrderEvent(store.EVENT_STATUS_SNAPSHOT, &order.OrderDetail{ID: "O2", Status: "CANCELED"}))
+
+	seen, err := OpenFileSeenStore(path, 0)
+	assert.NoError(t, err)
+	handled := make([]string, 0)
+	handler := func(e Event) error {
+		handled = append(handled, e.EventID)
+		return nil
+	}
+	p := &Processor{Seen: seen, Orders: orders, Handler: handler}
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	p.Seen.Claim("E9")
+	p.Seen.Release("E9")
+	assert.NoError(t, seen.Close())
+
+	// 重启后
+	seen, err = OpenFileSeenStore(path, 0)
+	assert.NoError(t, err)
+	defer seen.Close()
+	p = &Processor{Seen: seen, Orders: orders, Handler: handler}
+	assert.NoError(t, p.Handle(statusEvent("E1", "O1", "ON_GOING", "2023-06-01T10:00:00.00Z")))
+	assert.NoError(t, p.Handle(statusEvent("E9", "O1", "PICKED_UP", "2023-06-01T10:20:00.00Z")))
+	// 已取消订单的状态事件过期
+	assert.NoError(t, p.Handle(statusEvent("E10", "O2", "ON_GOING", "2023-06-01T10:20:00.00Z")))
+
+	assert.Equal(t, []string{"E1", "E9"}, handled)
+}
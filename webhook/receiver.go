@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 拒绝原因
+const (
+	REJECT_METHOD    = "method"
+	REJECT_MALFORMED = "malformed"
+	REJECT_APIKEY    = "apikey"
+	REJECT_SIGNATURE = "signature"
+	REJECT_EXPIRED   = "expired"
+	REJECT_HANDLER   = "handler"
+)
+
+// 请求体大小上限
+const maxBodySize = 1 << 20
+
+// Handler	事件处理函数; 返回错误时响应 500, Lalamove 将重新推送
+type Handler func(e Event) error
+
+// Observer	接收结果观察者 (如指标统计)
+type Observer interface {
+	// Received	事件校验通过
+	Received(eventType string)
+	// Rejected	事件被拒绝
+	Rejected(reason string)
+}
+
+// Receiver	webhook 接收器 (http.Handler)
+type Receiver struct {
+	Secret string
+	// 可选; 不为空时校验事件中的 apikey
+	Apikey string
+	// 可选; 签名使用的请求路径, 默认取请求的路径
+	Path string
+	// 可选; 允许的时间戳偏差, 为 0 时不校验
+	Tolerance time.Duration
+
+	Handler Handler
+	// 可选
+	Observer Observer
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+}
+
+// ServeHTTP	接收并校验事件.
+// 设置 webhook 地址时 Lalamove 会推送空内容以检查地址可用, 此时直接响应 200
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.reject(w, REJECT_METHOD, http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		r.reject(w, REJECT_MALFORMED, http.StatusBadRequest)
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || bytes.Equal(body, []byte("{}")) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	e := Event{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		r.reject(w, REJECT_MALFORMED, http.StatusBadRequest)
+		return
+	}
+
+	path := r.Path
+	if path == "" {
+		path = req.URL.Path
+	}
+	if reason, err := r.verify(path, e); err != nil {
+		r.reject(w, reason, http.StatusUnauthorized)
+		return
+	}
+
+	if r.Observer != nil {
+		r.Observer.Received(e.EventType)
+	}
+	if r.Handler != nil {
+		if err := r.Handler(e); err != nil {
+			r.reject(w, REJECT_HANDLER, http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify	校验 apikey、时间戳及签名; 失败时返回拒绝原因
+func (r *Receiver) verify(path string, e Event) (string, error) {
+	if r.Apikey != "" && e.Apikey != r.Apikey {
+		return REJECT_APIKEY, ErrApikey
+	}
+	if r.Tolerance > 0 {
+		now := time.Now()
+		if r.Now != nil {
+			now = r.Now()
+		}
+		diff := now.Sub(e.Time())
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > r.Tolerance {
+			return REJECT_EXPIRED, ErrExpired
+		}
+	}
+	if err := Verify(r.Secret, path, e); err != nil {
+		return REJECT_SIGNATURE, err
+	}
+	return "", nil
+}
+
+// reject	拒绝事件
+func (r *Receiver) reject(w http.ResponseWriter, reason string, status int) {
+	if r.Observer != nil {
+		r.Observer.Rejected(reason)
+	}
+	http.Error(w, reason, status)
+}
@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SeenStore	已处理事件ID存储; 用于事件去重
+type SeenStore interface {
+	// Claim	标记事件ID; 已被标记时返回 false
+	Claim(eventID string) (bool, error)
+	// Release	取消标记 (处理失败时), 以便重新推送的事件可再次处理
+	Release(eventID string) error
+}
+
+// 默认去重保留时长; 超过后事件ID被清理 (Lalamove 不会在此之后重新推送)
+const SEEN_TTL = 7 * 24 * time.Hour
+
+// MemorySeenStore	内存去重存储; 标记超过保留时长后清理
+type MemorySeenStore struct {
+	// 可选; 当前时间 (用于测试)
+	Now func() time.Time
+
+	mu sync.Mutex
+	ttl time.Duration
+	seen map[string]time.Time
+	pruned time.Time
+}
+
+// NewMemorySeenStore	创建内存去重存储; ttl 为标记保留时长, 不大于 0 时使用 SEEN_TTL
+func NewMemorySeenStore(ttl time.Duration) *MemorySeenStore {
+	if ttl <= 0 {
+		ttl = SEEN_TTL
+	}
+	return &MemorySeenStore{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// Claim	标记事件ID
+func (s *MemorySeenStore) Claim(eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.prune(now)
+	if s.claimed(eventID, now) {
+		return false, nil
+	}
+	s.seen[eventID] = now
+	return true, nil
+}
+
+// Release	取消标记
+func (s *MemorySeenStore) Release(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, eventID)
+	return nil
+}
+
+// Len	未过期的标记数量
+func (s *MemorySeenStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(s.now())
+	return len(s.seen)
+}
+
+// claimed	事件ID是否已标记且未过期 (调用方需持有锁)
+func (s *MemorySeenStore) claimed(eventID string, now time.Time) bool {
+	at, ok := s.seen[eventID]
+	return ok && now.Sub(at) < s.ttl
+}
+
+// prune	清理过期标记; 最多每 ttl/10 执行一次 (调用方需持有锁)
+func (s *MemorySeenStore) prune(now time.Time) {
+	if now.Sub(s.pruned) < s.ttl/10 {
+		return
+	}
+	for id, at := range s.seen {
+		if now.Sub(at) >= s.ttl {
+			delete(s.seen, id)
+		}
+	}
+	s.pruned = now
+}
+
+func (s *MemorySeenStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// seenRecord	去重文件记录
+type seenRecord struct {
+	EventID string `json:"eventId"`
+	// 标记时间; 旧版本记录为空, 按打开时间计算
+	At time.Time `json:"at,omitempty"`
+	// 取消标记
+	Released bool `json:"released,omitempty"`
+}
+
+// FileSeenStore	文件去重存储; 标记以 jsonl 格式追加写入文件, 打开时回放, 重启后仍可去重.
+// 打开时及追加记录数超过未过期标记数两倍时压缩文件, 只保留未过期的标记
+type FileSeenStore struct {
+	*MemorySeenStore
+
+	path string
+	file *os.File
+	// 上次压缩后追加的记录数
+	written int
+}
+
+// OpenFileSeenStore	打开 (或创建) 文件去重存储; ttl 为标记保留时长, 不大于 0 时使用 SEEN_TTL
+func OpenFileSeenStore(path string, ttl time.Duration) (*FileSeenStore, error) {
+	s := &FileSeenStore{
+		MemorySeenStore: NewMemorySeenStore(ttl),
+		path: path,
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay	回放历史标记; 末尾不完整的记录 (写入中断) 被忽略, 压缩时丢弃
+func (s *FileSeenStore) replay() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	now := s.now()
+	reader := bufio.NewReader(file)
+	line, badLine := 0, 0
+	var badErr error
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			if text := bytes.TrimSpace(data); len(text) > 0 {
+				if badErr != nil {
+					// 损坏的记录之后仍有记录, 不是写入中断
+					return fmt.Errorf("webhook: %s line %d: %w", s.path, badLine, badErr)
+				}
+				r := seenRecord{}
+				if jerr := json.Unmarshal(text, &r); jerr != nil {
+					badLine, badErr = line, jerr
+				} else if r.Released {
+					delete(s.seen, r.EventID)
+				} else {
+					if r.At.IsZero() {
+						r.At = now
+					}
+					s.seen[r.EventID] = r.At
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	s.prune(now)
+	return nil
+}
+
+// compact	重写文件, 只保留未过期的标记, 并重新以追加方式打开 (调用方需持有锁或尚未共享)
+func (s *FileSeenStore) compact() error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return err
+		}
+		s.file = nil
+	}
+
+	tmp := s.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	now := s.now()
+	for id, at := range s.seen {
+		if now.Sub(at) >= s.ttl {
+			continue
+		}
+		line, err := json.Marshal(seenRecord{EventID: id, At: at})
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	s.written = 0
+	return err
+}
+
+// Claim	标记事件ID并写入文件
+func (s *FileSeenStore) Claim(eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.prune(now)
+	if s.claimed(eventID, now) {
+		return false, nil
+	}
+	if err := s.write(seenRecord{EventID: eventID, At: now}); err != nil {
+		return false, err
+	}
+	s.seen[eventID] = now
+	return true, nil
+}
+
+// Release	取消标记并写入文件
+func (s *FileSeenStore) Release(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[eventID]; !ok {
+		return nil
+	}
+	if err := s.write(seenRecord{EventID: eventID, Released: true}); err != nil {
+		return err
+	}
+	delete(s.seen, eventID)
+	return nil
+}
+
+// write	追加记录; 追加记录过多时压缩文件 (调用方需持有锁)
+func (s *FileSeenStore) write(r seenRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if s.written++; s.written > 1024 && s.written > 2*len(s.seen) {
+		return s.compact()
+	}
+	return nil
+}
+
+// Close	关闭文件
+func (s *FileSeenStore) Close() error {
+	return s.file.Close()
+}
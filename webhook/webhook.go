@@ -0,0 +1,128 @@
+// Package webhook	Lalamove webhook 事件接收; 校验签名后交给处理函数
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eddielau42/lalamove-go-api/model/driver"
+	"github.com/eddielau42/lalamove-go-api/model/order"
+	"github.com/eddielau42/lalamove-go-api/model/quotation"
+	"github.com/eddielau42/lalamove-go-api/util"
+)
+
+// 事件类型
+const (
+	EVENT_ORDER_STATUS_CHANGED   = "ORDER_STATUS_CHANGED"
+	EVENT_DRIVER_ASSIGNED        = "DRIVER_ASSIGNED"
+	EVENT_ORDER_AMOUNT_CHANGED   = "ORDER_AMOUNT_CHANGED"
+	EVENT_ORDER_REPLACED         = "ORDER_REPLACED"
+	EVENT_ORDER_EDITED           = "ORDER_EDITED"
+	EVENT_WALLET_BALANCE_CHANGED = "WALLET_BALANCE_CHANGED"
+)
+
+var (
+	// ErrSignature	签名校验失败
+	ErrSignature = errors.New("webhook: invalid signature")
+	// ErrApikey	apikey 不匹配
+	ErrApikey = errors.New("webhook: apikey mismatch")
+	// ErrExpired	时间戳超出允许范围
+	ErrExpired = errors.New("webhook: timestamp out of tolerance")
+)
+
+// Event	webhook 事件
+type Event struct {
+	Apikey string `json:"apiKey"`
+	// 秒级时间戳
+	Timestamp int64 `json:"timestamp"`
+	Signature string `json:"signature"`
+	EventID string `json:"eventId"`
+	EventType string `json:"eventType"`
+	EventVersion string `json:"eventVersion"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Order	事件中的订单信息
+type Order struct {
+	order.OrderDetail
+	PreviousStatus string `json:"previousStatus,omitempty"`
+	Market string `json:"market,omitempty"`
+	ScheduleAt string `json:"scheduleAt,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// ScheduledAt	预约取货时间; 立即下单时返回零值
+func (o Order) ScheduledAt() (time.Time, error) {
+	return quotation.ParseScheduleAt(o.ScheduleAt)
+}
+
+// Balance	钱包余额
+type Balance struct {
+	Currency string `json:"currency"`
+	Amount string `json:"amount"`
+}
+
+// Data	事件内容; 不同事件类型携带不同字段
+type Data struct {
+	Order *Order `json:"order,omitempty"`
+	Driver *driver.DriverDetail `json:"driver,omitempty"`
+	Location *quotation.Coordinates `json:"location,omitempty"`
+	PrevOrderID string `json:"prevOrderId,omitempty"`
+	Balance *Balance `json:"balance,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Decode	解析事件内容
+func (e Event) Decode() (*Data, error) {
+	data := &Data{}
+	if len(e.Data) == 0 {
+		return data, nil
+	}
+	if err := json.Unmarshal(e.Data, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Time	事件时间
+func (e Event) Time() time.Time {
+	return time.Unix(e.Timestamp, 0)
+}
+
+// OrderID	事件关联的订单ID
+func (e Event) OrderID() string {
+	data, err := e.Decode()
+	if err != nil || data.Order == nil {
+		return ""
+	}
+	return data.Order.ID
+}
+
+// message	签名原文; path 为 webhook 地址的请求路径
+func message(timestamp int64, path string, data []byte) string {
+	return fmt.Sprintf("%s\r\nPOST\r\n%s\r\n\r\n%s", strconv.FormatInt(timestamp, 10), path, data)
+}
+
+// Sign	使用 secret 为事件签名
+func Sign(secret, path string, e *Event) {
+	e.Signature = util.Signature(secret, message(e.Timestamp, path, e.Data))
+}
+
+// Verify	校验事件签名
+func Verify(secret, path string, e Event) error {
+	got, err := hex.DecodeString(e.Signature)
+	if err != nil || len(got) == 0 {
+		return ErrSignature
+	}
+	want, _ := hex.DecodeString(util.Signature(secret, message(e.Timestamp, path, e.Data)))
+	// 常量时间比较, 避免时序攻击
+	if !hmac.Equal(got, want) {
+		return ErrSignature
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSecret = "sk_test_secret"
+	testPath = "/lalamove/webhook"
+)
+
+// observer	记录接收结果
+type observer struct {
+	received []string
+	rejected []string
+}
+
+func (o *observer) Received(eventType string) { o.received = append(o.received, eventType) }
+func (o *observer) Rejected(reason string) { o.rejected = append(o.rejected, reason) }
+
+func signedEvent(ts time.Time) Event {
+	e := Event{
+		Apikey: "pk_test_key",
+		Timestamp: ts.Unix(),
+		EventID: "EVT1",
+		EventType: EVENT_ORDER_STATUS_CHANGED,
+		EventVersion: "v3",
+		Data: json.RawMessage(`{"order":{"orderId":"O1","status":"PICKED_UP","previousStatus":"ON_GOING"},"updatedAt":"2023-06-01T10:00:00.00Z"}`),
+	}
+	Sign(testSecret, testPath, &e)
+	return e
+}
+
+func post(h http.Handler, body []byte) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, testPath, bytes.NewReader(body)))
+	return rec.Code
+}
+
+func TestReceiver(t *testing.T) {
+	now := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	obs := &observer{}
+	handled := make([]Event, 0)
+	failNext := false
+	r := &Receiver{
+		Secret: testSecret,
+		Apikey: "pk_test_key",
+		Tolerance: 5 * time.Minute,
+		Observer: obs,
+		Now: func() time.Time { return now },
+		Handler: func(e Event) error {
+			if failNext {
+				return errors.New("busy")
+			}
+			handled = append(handled, e)
+			return nil
+		},
+	}
+
+	// 设置地址时的空内容检查
+	assert.Equal(t, http.StatusOK, post(r, nil))
+
+	e := signedEvent(now)
+	body, _ := json.Marshal(e)
+	assert.Equal(t, http.StatusOK, post(r, body))
+	if assert.Len(t, handled, 1) {
+		data, err := handled[0].Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, "O1", data.Order.ID)
+		assert.Equal(t, "PICKED_UP", data.Order.Status)
+		assert.Equal(t, "ON_GOING", data.Order.PreviousStatus)
+		assert.Equal(t, "O1", handled[0].OrderID())
+	}
+
+	// 篡改内容
+	tampered := e
+	tampered.Data = json.RawMessage(`{"order":{"orderId":"O1","status":"COMPLETED"}}`)
+	body, _ = json.Marshal(tampered)
+	assert.Equal(t, http.StatusUnauthorized, post(r, body))
+
+	// 过期
+	body, _ = json.Marshal(signedEvent(now.Add(-time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, post(r, body))
+
+	// apikey 不匹配
+	other := signedEvent(now)
+	other.Apikey = "pk_other"
+	body, _ = json.Marshal(other)
+	assert.Equal(t, http.StatusUnauthorized, post(r, body))
+
+	assert.Equal(t, http.StatusBadRequest, post(r, []byte("not json")))
+
+	// 处理失败时响应 500 以便重新推送
+	failNext = true
+	body, _ = json.Marshal(e)
+	assert.Equal(t, http.StatusInternalServerError, post(r, body))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, testPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	assert.Equal(t, []string{EVENT_ORDER_STATUS_CHANGED, EVENT_ORDER_STATUS_CHANGED}, obs.received)
+	assert.Equal(t, []string{REJECT_SIGNATURE, REJECT_EXPIRED, REJECT_APIKEY, REJECT_MALFORMED, REJECT_HANDLER, REJECT_METHOD}, obs.rejected)
+}
+
+func TestVerify(t *testing.T) {
+	e := signedEvent(time.Unix(1685613600, 0))
+	assert.NoError(t, Verify(testSecret, testPath, e))
+	assert.ErrorIs(t, Verify("sk_other", testPath, e), ErrSignature)
+	assert.ErrorIs(t, Verify(testSecret, "/other", e), ErrSignature)
+
+	tampered := e
+	tampered.Signature = "not-hex"
+	assert.ErrorIs(t, Verify(testSecret, testPath, tampered), ErrSignature)
+	tampered.Signature = ""
+	assert.ErrorIs(t, Verify(testSecret, testPath, tampered), ErrSignature)
+	tampered.Signature = e.Signature[:len(e.Signature)-2]
+	assert.ErrorIs(t, Verify(testSecret, testPath, tampered), ErrSignature)
+}
+
+func TestEventLogRedeliver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	log, err := OpenEventLog(path)
+	assert.NoError(t, err)
+	e := signedEvent(time.Now())
+	assert.NoError(t, log.Append(e))
+	assert.NoError(t, log.Close())
+
+	events, err := ReadEvents(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{e}, events)
+
+	// 推送到其他路径时重新签名
+	received := make([]Event, 0)
+	srv := httptest.NewServer(&Receiver{
+		Secret: testSecret,
+		Handler: func(e Event) error {
+			received = append(received, e)
+			return nil
+		},
+	})
+	defer srv.Close()
+
+	assert.NoError(t, Deliver(context.Background(), nil, srv.URL+"/dev/hook", testSecret, events[0]))
+	assert.Error(t, Deliver(context.Background(), nil, srv.URL+"/dev/hook", "", events[0]))
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "EVT1", received[0].EventID)
+	}
+}